@@ -0,0 +1,41 @@
+package booking
+
+import (
+	"context"
+	"time"
+)
+
+// DayAvailability lists the rooms available during an interval of a day
+type DayAvailability struct {
+	Start time.Time
+	End   time.Time
+	Rooms []Room
+}
+
+// RangeService is implemented by booking services which can find the
+// available rooms of several days at once.
+type RangeService interface {
+	// AvailableRange returns the rooms available between the time of day of
+	// start and the time of day of end, for every day from the date of start
+	// up until and including the date of end.
+	AvailableRange(ctx context.Context, start time.Time, end time.Time) ([]DayAvailability, error)
+}
+
+// Days returns the interval of every day of a range as described by
+// RangeService, without any rooms. Days where the interval would be empty,
+// because end is earlier in the day than start, are left out.
+func Days(start time.Time, end time.Time) []DayAvailability {
+	var days []DayAvailability
+	last := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, start.Location())
+	for d := 0; ; d++ {
+		day := time.Date(start.Year(), start.Month(), start.Day()+d, 0, 0, 0, 0, start.Location())
+		if day.After(last) {
+			return days
+		}
+		dayStart := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+		dayEnd := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), end.Second(), 0, start.Location())
+		if dayEnd.After(dayStart) {
+			days = append(days, DayAvailability{Start: dayStart, End: dayEnd})
+		}
+	}
+}
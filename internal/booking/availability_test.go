@@ -0,0 +1,40 @@
+package booking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDays(t *testing.T) {
+	start := time.Date(2020, 9, 28, 13, 0, 0, 0, time.UTC)
+	end := time.Date(2020, 10, 2, 15, 30, 0, 0, time.UTC)
+
+	days := Days(start, end)
+	assert.Len(t, days, 5)
+	assert.Equal(t, start, days[0].Start)
+	assert.Equal(t, time.Date(2020, 9, 28, 15, 30, 0, 0, time.UTC), days[0].End)
+	assert.Equal(t, time.Date(2020, 10, 1, 13, 0, 0, 0, time.UTC), days[3].Start, "Days should continue into the next month")
+	assert.Equal(t, end, days[4].End)
+
+	assert.Empty(t, Days(end, start), "Ranges ending before they start have no days")
+}
+
+func TestDays_DaylightSaving(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	// Sweden switches to winter time during the night to the 25th
+	start := time.Date(2020, 10, 24, 10, 0, 0, 0, stockholm)
+	end := time.Date(2020, 10, 26, 12, 0, 0, 0, stockholm)
+
+	days := Days(start, end)
+	assert.Len(t, days, 3)
+	for _, day := range days {
+		assert.Equal(t, 10, day.Start.Hour(), "Days should start at the same time of day")
+		assert.Equal(t, 2*time.Hour, day.End.Sub(day.Start))
+	}
+}
@@ -1,10 +1,27 @@
 package booking
 
-import "time"
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
 
 type BookingService interface {
-	Book(booking Booking) error
-	UnBook(booking Booking) error
-	MyBookings() ([]Booking, error)
-	Available(start time.Time, end time.Time) ([]Room, error)
+	// Book books the room and returns the created booking, including the Id
+	// the provider gave it
+	Book(ctx context.Context, booking Booking) (Booking, error)
+	UnBook(ctx context.Context, booking Booking) error
+	MyBookings(ctx context.Context) ([]Booking, error)
+	Available(ctx context.Context, start time.Time, end time.Time) ([]Room, error)
+}
+
+// FormService is implemented by booking services which can tell what they
+// would send to the provider to book a room or remove a booking, without
+// doing it
+type FormService interface {
+	BookingForm(booking Booking) (url.Values, error)
+	// UnBookingRequest returns the request removing the booking, which
+	// hasn't been sent
+	UnBookingRequest(ctx context.Context, booking Booking) (*http.Request, error)
 }
@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/directory"
+)
+
+const (
+	availableKey   = "available"
+	bookingsKey    = "bookings"
+	bookingsNoText = "bookings-without-text"
+	timelineKey    = "timeline"
+	rangeKey       = "range"
+)
+
+// BookingService caches the rooms available and the bookings of another
+// booking service for a short while. Booking or unbooking a room removes
+// the cached results it affects.
+type BookingService struct {
+	bs   booking.BookingService
+	opts Options
+}
+
+type Options struct {
+	// Store keeps the cached results, a MemoryStore is used if it isn't set
+	Store Store
+	// Namespace separates the results of different users or providers
+	// sharing a store
+	Namespace string
+	// AvailableTTL is how long available rooms are cached, 0 disables it
+	AvailableTTL time.Duration
+	// BookingsTTL is how long the bookings of the user are cached, 0
+	// disables it
+	BookingsTTL time.Duration
+}
+
+// NewBookingService returns a service caching the results of bs
+func NewBookingService(bs booking.BookingService, opts Options) *BookingService {
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore()
+	}
+	return &BookingService{bs: bs, opts: opts}
+}
+
+func (c *BookingService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	created, err := c.bs.Book(ctx, b)
+	if err == nil || errors.Is(err, booking.ErrRoomUnavailable) || errors.Is(err, booking.ErrUnconfirmed) {
+		// A room turning out to be taken means that the cached rooms were
+		// wrong about it
+		c.invalidate(b)
+	}
+	return created, err
+}
+
+func (c *BookingService) UnBook(ctx context.Context, b booking.Booking) error {
+	err := c.bs.UnBook(ctx, b)
+	// Even a failed attempt might have removed the booking
+	c.invalidate(b)
+	return err
+}
+
+func (c *BookingService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	var bookings []booking.Booking
+	// Bookings with texts can be used whether or not the texts are needed
+	if c.get(c.key(bookingsKey), &bookings) {
+		return bookings, nil
+	}
+	key := c.key(bookingsKey)
+	if booking.SkipText(ctx) {
+		key = c.key(bookingsNoText)
+		if c.get(key, &bookings) {
+			return bookings, nil
+		}
+	}
+
+	bookings, err := c.bs.MyBookings(ctx)
+	if err != nil {
+		return bookings, err
+	}
+	c.set(key, time.Time{}, time.Time{}, c.opts.BookingsTTL, bookings)
+	return bookings, nil
+}
+
+func (c *BookingService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	key := c.intervalKey(availableKey, start, end)
+	var rooms []booking.Room
+	if c.get(key, &rooms) {
+		return rooms, nil
+	}
+
+	rooms, err := c.bs.Available(ctx, start, end)
+	if err != nil {
+		return rooms, err
+	}
+	c.set(key, start, end, c.opts.AvailableTTL, rooms)
+	return rooms, nil
+}
+
+func (c *BookingService) Timeline(ctx context.Context, start time.Time, end time.Time, step time.Duration) ([]booking.Timeline, error) {
+	key := c.intervalKey(timelineKey, start, end) + "/" + step.String()
+	var timelines []booking.Timeline
+	if c.get(key, &timelines) {
+		return timelines, nil
+	}
+
+	timelines, err := directory.Timeline(ctx, c.bs, start, end, step)
+	if err != nil {
+		return timelines, err
+	}
+	c.set(key, start, end, c.opts.AvailableTTL, timelines)
+	return timelines, nil
+}
+
+func (c *BookingService) AvailableRange(ctx context.Context, start time.Time, end time.Time) ([]booking.DayAvailability, error) {
+	key := c.intervalKey(rangeKey, start, end)
+	var days []booking.DayAvailability
+	if c.get(key, &days) {
+		return days, nil
+	}
+
+	days, err := directory.AvailableRange(ctx, c.bs, start, end)
+	if err != nil {
+		return days, err
+	}
+	c.set(key, start, end, c.opts.AvailableTTL, days)
+	return days, nil
+}
+
+// BookingForm returns what the cached service would send to book a room, or
+// nil if it can't tell
+func (c *BookingService) BookingForm(b booking.Booking) (url.Values, error) {
+	if fs, ok := c.bs.(booking.FormService); ok {
+		return fs.BookingForm(b)
+	}
+	return nil, nil
+}
+
+// UnBookingRequest returns what the cached service would send to remove a
+// booking, or nil if it can't tell
+func (c *BookingService) UnBookingRequest(ctx context.Context, b booking.Booking) (*http.Request, error) {
+	if fs, ok := c.bs.(booking.FormService); ok {
+		return fs.UnBookingRequest(ctx, b)
+	}
+	return nil, nil
+}
+
+// invalidate removes the bookings, and the available rooms of every
+// interval overlapping with b
+func (c *BookingService) invalidate(b booking.Booking) {
+	_ = c.opts.Store.Delete(func(e Entry) bool {
+		switch e.Key {
+		case c.key(bookingsKey), c.key(bookingsNoText):
+			return true
+		}
+		if e.Start.IsZero() {
+			return false
+		}
+		return e.Start.Before(b.End) && b.Start.Before(e.End)
+	})
+}
+
+func (c *BookingService) get(key string, v interface{}) bool {
+	e, ok := c.opts.Store.Get(key)
+	if !ok {
+		return false
+	}
+	// A broken entry is just a miss
+	return json.Unmarshal(e.Data, v) == nil
+}
+
+func (c *BookingService) set(key string, start time.Time, end time.Time, ttl time.Duration, v interface{}) {
+	if ttl <= 0 {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	// Failing to cache only makes the next call slower
+	_ = c.opts.Store.Set(Entry{
+		Key:     key,
+		Expires: time.Now().Add(ttl),
+		Start:   start,
+		End:     end,
+		Data:    data,
+	})
+}
+
+func (c *BookingService) key(kind string) string {
+	return fmt.Sprintf("%s/%s", c.opts.Namespace, kind)
+}
+
+func (c *BookingService) intervalKey(kind string, start time.Time, end time.Time) string {
+	return fmt.Sprintf("%s/%d-%d", c.key(kind), start.Unix(), end.Unix())
+}
@@ -0,0 +1,218 @@
+package cache
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/directory"
+	"sidus.io/boogrocha/internal/booking/local"
+	"sidus.io/boogrocha/internal/booking/local/localtest"
+	fmtLog "sidus.io/boogrocha/internal/log/fmt"
+)
+
+var (
+	roomA = booking.Room{Provider: local.BaseProvider, Id: "roomA", Seats: 4}
+	roomB = booking.Room{Provider: local.BaseProvider, Id: "roomB", Seats: 6}
+)
+
+// countingService counts the calls to the service it wraps
+type countingService struct {
+	local.BookingService
+	available  int
+	myBookings int
+	fail       bool
+}
+
+func (cs *countingService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	cs.available++
+	if cs.fail {
+		return nil, booking.ErrProviderUnreachable
+	}
+	return cs.BookingService.Available(ctx, start, end)
+}
+
+func (cs *countingService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	cs.myBookings++
+	return cs.BookingService.MyBookings(ctx)
+}
+
+func at(hour int) time.Time {
+	return time.Date(2020, 9, 28, hour, 0, 0, 0, time.UTC)
+}
+
+// newCountingService counts the calls to a local service with the rooms
+func newCountingService(t *testing.T, rooms ...booking.Room) *countingService {
+	return &countingService{BookingService: localtest.NewBookingService(t, at(0), rooms...)}
+}
+
+func TestBookingService_Available(t *testing.T) {
+	cs := newCountingService(t, roomA, roomB)
+	c := NewBookingService(cs, Options{AvailableTTL: time.Minute, BookingsTTL: time.Minute})
+	ctx := context.Background()
+
+	rooms, err := c.Available(ctx, at(8), at(10))
+	assert.NoError(t, err)
+	assert.Equal(t, []booking.Room{roomA, roomB}, rooms)
+	rooms, err = c.Available(ctx, at(8), at(10))
+	assert.NoError(t, err)
+	assert.Equal(t, []booking.Room{roomA, roomB}, rooms)
+	assert.Equal(t, 1, cs.available, "The second call should be cached")
+
+	_, _ = c.Available(ctx, at(12), at(14))
+	assert.Equal(t, 2, cs.available, "Other intervals shouldn't be cached")
+
+	_, err = c.Book(ctx, booking.Booking{Room: roomA, Start: at(9), End: at(11)})
+	assert.NoError(t, err)
+
+	rooms, _ = c.Available(ctx, at(8), at(10))
+	assert.Equal(t, []booking.Room{roomB}, rooms)
+	assert.Equal(t, 3, cs.available, "Booking should remove the overlapping intervals")
+	_, _ = c.Available(ctx, at(12), at(14))
+	assert.Equal(t, 3, cs.available, "Booking shouldn't remove other intervals")
+}
+
+func TestBookingService_MyBookings(t *testing.T) {
+	cs := newCountingService(t, roomA)
+	c := NewBookingService(cs, Options{AvailableTTL: time.Minute, BookingsTTL: time.Minute})
+	ctx := context.Background()
+
+	created, err := c.Book(ctx, booking.Booking{Room: roomA, Start: at(9), End: at(11)})
+	assert.NoError(t, err)
+
+	_, _ = c.MyBookings(booking.WithoutText(ctx))
+	bookings, _ := c.MyBookings(booking.WithoutText(ctx))
+	assert.Equal(t, 1, cs.myBookings)
+	assert.Len(t, bookings, 1)
+	assert.True(t, created.Start.Equal(bookings[0].Start))
+
+	_, _ = c.MyBookings(ctx)
+	assert.Equal(t, 2, cs.myBookings, "Bookings without texts shouldn't be used when the texts are needed")
+	_, _ = c.MyBookings(booking.WithoutText(ctx))
+	assert.Equal(t, 2, cs.myBookings, "Bookings with texts should be used when the texts aren't needed")
+
+	assert.NoError(t, c.UnBook(ctx, created))
+	bookings, _ = c.MyBookings(ctx)
+	assert.Equal(t, 3, cs.myBookings, "Unbooking should remove the cached bookings")
+	assert.Empty(t, bookings)
+}
+
+func TestBookingService_Expiry(t *testing.T) {
+	cs := newCountingService(t, roomA)
+	c := NewBookingService(cs, Options{AvailableTTL: 10 * time.Millisecond})
+	ctx := context.Background()
+
+	_, _ = c.Available(ctx, at(8), at(10))
+	_, _ = c.Available(ctx, at(8), at(10))
+	assert.Equal(t, 1, cs.available)
+
+	time.Sleep(20 * time.Millisecond)
+	_, _ = c.Available(ctx, at(8), at(10))
+	assert.Equal(t, 2, cs.available, "Expired results should be fetched again")
+
+	_, _ = c.MyBookings(ctx)
+	_, _ = c.MyBookings(ctx)
+	assert.Equal(t, 2, cs.myBookings, "Bookings shouldn't be cached without a TTL")
+}
+
+func TestBookingService_Errors(t *testing.T) {
+	cs := newCountingService(t, roomA)
+	cs.fail = true
+	c := NewBookingService(cs, Options{AvailableTTL: time.Minute})
+	ctx := context.Background()
+
+	_, err := c.Available(ctx, at(8), at(10))
+	assert.Error(t, err)
+	cs.fail = false
+	rooms, err := c.Available(ctx, at(8), at(10))
+	assert.NoError(t, err)
+	assert.Equal(t, []booking.Room{roomA}, rooms, "Failures shouldn't be cached")
+}
+
+// providerService has the same rooms available at all times, and books them
+// unless it refuses to
+type providerService struct {
+	rooms     []booking.Room
+	refuse    bool
+	available int
+}
+
+func (ps *providerService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	if ps.refuse {
+		return booking.Booking{}, booking.ErrRoomUnavailable
+	}
+	b.Id = "1"
+	return b, nil
+}
+
+func (ps *providerService) UnBook(ctx context.Context, b booking.Booking) error {
+	return nil
+}
+
+func (ps *providerService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	return nil, nil
+}
+
+func (ps *providerService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	ps.available++
+	return ps.rooms, nil
+}
+
+func TestBookingService_FailoverAfterCacheHit(t *testing.T) {
+	roomAX := booking.Room{Provider: "A", Id: "X"}
+	roomBX := booking.Room{Provider: "B", Id: "X"}
+	a := &providerService{rooms: []booking.Room{roomAX}, refuse: true}
+	b := &providerService{rooms: []booking.Room{roomBX}}
+	newDirectory := func() *directory.BookingService {
+		return directory.NewBookingServiceWithOptions(map[string]booking.BookingService{"A": a, "B": b},
+			&fmtLog.Logger{}, directory.Options{MergeRooms: true, Preferred: []string{"A"}})
+	}
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	_, err := NewBookingService(newDirectory(), Options{Store: store, AvailableTTL: time.Minute}).Available(ctx, at(8), at(10))
+	assert.NoError(t, err)
+
+	// A later run finds the rooms in the cache, without asking the providers
+	c := NewBookingService(newDirectory(), Options{Store: store, AvailableTTL: time.Minute})
+	rooms, err := c.Available(ctx, at(8), at(10))
+	assert.NoError(t, err)
+	assert.Equal(t, []booking.Room{roomAX}, rooms)
+	assert.Equal(t, 1, b.available)
+
+	created, err := c.Book(ctx, booking.Booking{Room: rooms[0], Start: at(8), End: at(10)})
+	assert.NoError(t, err)
+	assert.Equal(t, roomBX, created.Room, "The room should be booked through the other provider")
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	assert.NoError(t, err)
+	cs := newCountingService(t, roomA)
+	c := NewBookingService(cs, Options{Store: store, Namespace: "user", AvailableTTL: time.Minute})
+	ctx := context.Background()
+	_, _ = c.Available(ctx, at(8), at(10))
+
+	// A later run should find the results of the earlier one
+	store, err = NewFileStore(dir)
+	assert.NoError(t, err)
+	c = NewBookingService(cs, Options{Store: store, Namespace: "user", AvailableTTL: time.Minute})
+	rooms, err := c.Available(ctx, at(8), at(10))
+	assert.NoError(t, err)
+	assert.Equal(t, []booking.Room{roomA}, rooms)
+	assert.Equal(t, 1, cs.available)
+
+	other := NewBookingService(cs, Options{Store: store, Namespace: "other", AvailableTTL: time.Minute})
+	_, _ = other.Available(ctx, at(8), at(10))
+	assert.Equal(t, 2, cs.available, "Namespaces shouldn't share results")
+
+	_, err = c.Book(ctx, booking.Booking{Room: roomA, Start: at(9), End: at(10)})
+	assert.NoError(t, err)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 0, "Booking should remove the entries of the interval")
+}
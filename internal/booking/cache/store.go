@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a cached result. Start and End are the interval the result is
+// about, and are zero for results that aren't about an interval.
+type Entry struct {
+	Key     string
+	Expires time.Time
+	Start   time.Time
+	End     time.Time
+	Data    json.RawMessage
+}
+
+func (e Entry) expired() bool {
+	return !time.Now().Before(e.Expires)
+}
+
+// Store keeps cached results
+type Store interface {
+	// Get returns the entry with the key unless it has expired
+	Get(key string) (Entry, bool)
+	Set(e Entry) error
+	// Delete removes every entry for which match returns true
+	Delete(match func(Entry) bool) error
+}
+
+// MemoryStore keeps the entries for as long as the process runs
+type MemoryStore struct {
+	mutex   sync.Mutex
+	entries map[string]Entry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Entry)}
+}
+
+func (s *MemoryStore) Get(key string) (Entry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || e.expired() {
+		delete(s.entries, key)
+		return Entry{}, false
+	}
+	return e, true
+}
+
+func (s *MemoryStore) Set(e Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries[e.Key] = e
+	return nil
+}
+
+func (s *MemoryStore) Delete(match func(Entry) bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, e := range s.entries {
+		if match(e) {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
+
+// FileStore keeps every entry in a file of its own in a folder, so that the
+// entries can be used by later runs
+type FileStore struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+	err := os.MkdirAll(path, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{path: path}, nil
+}
+
+func (s *FileStore) Get(key string) (Entry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file := s.file(key)
+	e, err := readEntry(file)
+	if err != nil || e.Key != key {
+		return Entry{}, false
+	}
+	if e.expired() {
+		os.Remove(file)
+		return Entry{}, false
+	}
+	return e, true
+}
+
+func (s *FileStore) Set(e Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	// Write through a rename so that other runs never read half an entry
+	tmp, err := ioutil.TempFile(s.path, "entry-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.file(e.Key))
+}
+
+func (s *FileStore) Delete(match func(Entry) bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	files, err := ioutil.ReadDir(s.path)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		file := filepath.Join(s.path, f.Name())
+		e, err := readEntry(file)
+		// Entries that can't be read are as good as gone
+		if err != nil || e.expired() || match(e) {
+			err = os.Remove(file)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) file(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(s.path, hex.EncodeToString(sum[:])+".json")
+}
+
+func readEntry(file string) (Entry, error) {
+	var e Entry
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return e, err
+	}
+	err = json.Unmarshal(data, &e)
+	return e, err
+}
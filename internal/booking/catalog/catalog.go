@@ -0,0 +1,268 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fetching the catalog gives up after this long so that an unresponsive
+// host doesn't hold up every command
+const fetchTimeout = 10 * time.Second
+
+// After a failed fetch the catalog isn't fetched again for this long, so that
+// commands don't wait for a host which is down
+const retryDelay = time.Hour
+
+const (
+	cacheFile    = "rooms.json"
+	metaFile     = "rooms.meta.json"
+	OverrideFile = "rooms.local.json"
+)
+
+// Info is the metadata about a room which the booking systems don't provide
+type Info struct {
+	Seats    int    `json:"seats"`
+	Campus   string `json:"campus"`
+	Building string `json:"building,omitempty"`
+	Floor    string `json:"floor,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// Catalog maps room names to their metadata
+type Catalog map[string]Info
+
+// Names returns the names of all rooms in the catalog in alphabetical order
+func (c Catalog) Names() []string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type meta struct {
+	ETag    string
+	Fetched time.Time
+	// Failed is when the catalog last failed to be fetched
+	Failed time.Time
+}
+
+// Service provides the room catalog hosted at a url. The catalog is cached
+// on disk and only fetched again when older than maxAge. If the catalog
+// can't be fetched the cached copy, or the snapshot built into bgc, is used
+// instead. Entries in the local override file are layered on top.
+type Service struct {
+	url    string
+	path   string
+	maxAge time.Duration
+	client *http.Client
+
+	mutex   sync.Mutex
+	catalog Catalog
+}
+
+func NewService(url string, path string, maxAge time.Duration) *Service {
+	return &Service{
+		url:    url,
+		path:   path,
+		maxAge: maxAge,
+		client: http.DefaultClient,
+	}
+}
+
+// Get returns the catalog, fetching it only if the cached copy is too old
+func (s *Service) Get(ctx context.Context) (Catalog, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.catalog != nil {
+		return s.catalog, nil
+	}
+
+	m, _ := s.readMeta()
+	if time.Since(m.Fetched) > s.maxAge && time.Since(m.Failed) > retryDelay {
+		fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		_, err := s.fetch(fetchCtx, m)
+		cancel()
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			// Any other failure falls back to the cached copy or the snapshot
+			m.Failed = time.Now()
+			_ = s.writeMeta(m)
+		}
+	}
+
+	return s.load()
+}
+
+// Sync fetches the catalog even if the cached copy isn't too old yet. It
+// reports whether the catalog had changed since it was last fetched.
+func (s *Service) Sync(ctx context.Context) (Catalog, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	m, _ := s.readMeta()
+	changed, err := s.fetch(ctx, m)
+	if err != nil {
+		return nil, false, err
+	}
+
+	catalog, err := s.load()
+	return catalog, changed, err
+}
+
+// fetch downloads the catalog into the cache unless it's unchanged
+func (s *Service) fetch(ctx context.Context, m meta) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url, nil)
+	if err != nil {
+		return false, err
+	}
+	if m.ETag != "" && s.exists(cacheFile) {
+		req.Header.Set("If-None-Match", m.ETag)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		m.Fetched, m.Failed = time.Now(), time.Time{}
+		return false, s.writeMeta(m)
+	case http.StatusOK:
+	default:
+		return false, fmt.Errorf("failed to fetch room catalog (%d)", resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	// Make sure the catalog is valid before replacing the cached copy
+	var catalog Catalog
+	err = json.Unmarshal(data, &catalog)
+	if err != nil {
+		return false, fmt.Errorf("invalid room catalog: %w", err)
+	}
+
+	err = s.write(cacheFile, data)
+	if err != nil {
+		return false, err
+	}
+	s.catalog = nil
+
+	return true, s.writeMeta(meta{
+		ETag:    resp.Header.Get("ETag"),
+		Fetched: time.Now(),
+	})
+}
+
+// load reads the cached catalog, or the snapshot if there is none, and
+// applies the local overrides
+func (s *Service) load() (Catalog, error) {
+	data, err := ioutil.ReadFile(s.file(cacheFile))
+	if err != nil {
+		data = snapshot
+	}
+
+	var catalog Catalog
+	err = json.Unmarshal(data, &catalog)
+	if err != nil {
+		err = json.Unmarshal(snapshot, &catalog)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// A catalog of null decodes to a nil map
+	if catalog == nil {
+		catalog = make(Catalog)
+	}
+
+	err = s.applyOverrides(catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	s.catalog = catalog
+	return catalog, nil
+}
+
+// applyOverrides layers the local override file on top of the catalog. Only
+// the fields present in the override file replace those of the catalog.
+func (s *Service) applyOverrides(catalog Catalog) error {
+	data, err := ioutil.ReadFile(s.file(OverrideFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var overrides map[string]json.RawMessage
+	err = json.Unmarshal(data, &overrides)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", OverrideFile, err)
+	}
+
+	for name, override := range overrides {
+		info := catalog[name]
+		err = json.Unmarshal(override, &info)
+		if err != nil {
+			return fmt.Errorf("invalid entry for %s in %s: %w", name, OverrideFile, err)
+		}
+		catalog[name] = info
+	}
+	return nil
+}
+
+func (s *Service) readMeta() (meta, error) {
+	var m meta
+	data, err := ioutil.ReadFile(s.file(metaFile))
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+func (s *Service) writeMeta(m meta) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return s.write(metaFile, data)
+}
+
+func (s *Service) write(name string, data []byte) error {
+	// Create folder if it doesnt exist
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		err = os.MkdirAll(s.path, 0744)
+		if err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(s.file(name), data, 0644)
+}
+
+func (s *Service) exists(name string) bool {
+	_, err := os.Stat(s.file(name))
+	return err == nil
+}
+
+func (s *Service) file(name string) string {
+	return filepath.Join(s.path, name)
+}
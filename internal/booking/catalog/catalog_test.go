@@ -0,0 +1,194 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCatalog = `{"EG-2515": {"seats": 6, "campus": "Johanneberg"}, "SB-G311": {"seats": 4, "campus": "Johanneberg"}}`
+
+func newTestServer(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, err := w.Write([]byte(testCatalog))
+		assert.NoError(t, err)
+	}))
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "catalog")
+	assert.NoError(t, err)
+	return dir
+}
+
+func TestService_Get(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	requests := 0
+	server := newTestServer(t, &requests)
+	defer server.Close()
+
+	catalog, err := NewService(server.URL, dir, time.Hour).Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"EG-2515", "SB-G311"}, catalog.Names())
+	assert.Equal(t, 6, catalog["EG-2515"].Seats)
+	assert.Equal(t, 1, requests)
+
+	// A fresh cached copy shouldn't be fetched again
+	catalog, err = NewService(server.URL, dir, time.Hour).Get(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, catalog, 2)
+	assert.Equal(t, 1, requests)
+}
+
+func TestService_Sync(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	requests := 0
+	server := newTestServer(t, &requests)
+	defer server.Close()
+
+	s := NewService(server.URL, dir, time.Hour)
+
+	_, changed, err := s.Sync(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	catalog, changed, err := s.Sync(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, changed, "The ETag should match the cached copy")
+	assert.Len(t, catalog, 2)
+	assert.Equal(t, 2, requests)
+}
+
+func TestService_Fallback(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	requests := 0
+	server := newTestServer(t, &requests)
+
+	_, _, err := NewService(server.URL, dir, time.Hour).Sync(context.Background())
+	assert.NoError(t, err)
+	server.Close()
+
+	// An outdated cached copy should still be used when the host is down
+	catalog, err := NewService(server.URL, dir, 0).Get(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, catalog, 2)
+
+	_, _, err = NewService(server.URL, dir, 0).Sync(context.Background())
+	assert.Error(t, err, "Syncing should report that the host is down")
+
+	// Without a cached copy the snapshot is used
+	empty := tempDir(t)
+	defer os.RemoveAll(empty)
+	catalog, err = NewService(server.URL, empty, 0).Get(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, catalog)
+}
+
+func TestService_InvalidCatalog(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	requests := 0
+	server := newTestServer(t, &requests)
+	defer server.Close()
+
+	_, _, err := NewService(server.URL, dir, time.Hour).Sync(context.Background())
+	assert.NoError(t, err)
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html>"))
+	}))
+	defer broken.Close()
+
+	_, _, err = NewService(broken.URL, dir, 0).Sync(context.Background())
+	assert.Error(t, err)
+
+	catalog, err := NewService(broken.URL, dir, 0).Get(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, catalog, 2, "An invalid catalog shouldn't replace the cached copy")
+}
+
+func TestService_Overrides(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	requests := 0
+	server := newTestServer(t, &requests)
+	defer server.Close()
+
+	overrides := `{"EG-2515": {"seats": 8, "floor": "5"}, "Bibliotek 1": {"seats": 2, "notes": "Whiteboard"}}`
+	err := ioutil.WriteFile(filepath.Join(dir, OverrideFile), []byte(overrides), 0644)
+	assert.NoError(t, err)
+
+	catalog, err := NewService(server.URL, dir, time.Hour).Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, Info{Seats: 8, Campus: "Johanneberg", Floor: "5"}, catalog["EG-2515"],
+		"Only the fields in the override file should be replaced")
+	assert.Equal(t, Info{Seats: 2, Notes: "Whiteboard"}, catalog["Bibliotek 1"])
+	assert.Equal(t, 4, catalog["SB-G311"].Seats)
+}
+
+func TestService_RetryDelay(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	requests := 0
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	_, err := NewService(down.URL, dir, 0).Get(context.Background())
+	assert.NoError(t, err)
+	_, err = NewService(down.URL, dir, 0).Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests, "A failed fetch shouldn't be retried right away")
+
+	// Syncing is asked for explicitly and always fetches
+	_, _, err = NewService(down.URL, dir, 0).Sync(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestService_NullCatalog(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	null := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("null"))
+	}))
+	defer null.Close()
+
+	err := ioutil.WriteFile(filepath.Join(dir, OverrideFile), []byte(`{"EG-2515": {"seats": 8}}`), 0644)
+	assert.NoError(t, err)
+
+	catalog, err := NewService(null.URL, dir, 0).Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 8, catalog["EG-2515"].Seats)
+}
+
+func TestSnapshot(t *testing.T) {
+	var catalog Catalog
+	err := json.Unmarshal(snapshot, &catalog)
+	assert.NoError(t, err, "The snapshot should be a valid catalog")
+	if len(catalog) == 0 {
+		t.Skip("the snapshot is empty, refresh it by running go generate with network access")
+	}
+	for name := range catalog {
+		assert.NotEmpty(t, name)
+	}
+}
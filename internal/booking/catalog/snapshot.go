@@ -0,0 +1,8 @@
+package catalog
+
+//go:generate go run snapshot_gen.go
+
+// snapshot is the catalog used when the hosted catalog can't be fetched and
+// there is no cached copy. It is refreshed from the hosted catalog by running
+// go generate with network access.
+var snapshot = []byte(`{}`)
@@ -0,0 +1,80 @@
+// +build ignore
+
+// This program downloads the hosted room catalog and writes it to
+// snapshot.go so that it can be built into bgc. Without network access a
+// copy of the catalog, such as ~/.BooGroCha/rooms.json, can be given instead:
+//
+//	go run snapshot_gen.go rooms.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const url = "https://boogrocha.sidus.io/rooms.json"
+
+func main() {
+	var data []byte
+	var err error
+	if len(os.Args) > 1 {
+		data, err = ioutil.ReadFile(os.Args[1])
+	} else {
+		data, err = download()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The snapshot is only useful if it's a catalog with rooms in it
+	var catalog map[string]json.RawMessage
+	err = json.Unmarshal(data, &catalog)
+	if err != nil {
+		log.Fatalf("invalid room catalog: %v", err)
+	}
+	if len(catalog) == 0 {
+		log.Fatal("room catalog is empty")
+	}
+
+	var indented bytes.Buffer
+	err = json.Indent(&indented, data, "", "\t")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if strings.Contains(indented.String(), "`") {
+		log.Fatal("room catalog can't contain backticks")
+	}
+
+	src := fmt.Sprintf(`package catalog
+
+//go:generate go run snapshot_gen.go
+
+// snapshot is the catalog used when the hosted catalog can't be fetched and
+// there is no cached copy. It is refreshed from the hosted catalog by running
+// go generate with network access.
+var snapshot = []byte(%s)
+`, "`"+indented.String()+"`")
+
+	err = ioutil.WriteFile("snapshot.go", []byte(src), 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func download() ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch room catalog (%d)", resp.StatusCode)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,34 @@
+package booking
+
+import "context"
+
+type contextKey int
+
+const (
+	skipTextKey contextKey = iota
+	allowOverlapKey
+)
+
+// WithoutText tells booking services that the texts of the bookings returned
+// by MyBookings aren't needed, which lets them skip fetching them
+func WithoutText(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipTextKey, true)
+}
+
+// SkipText tells if the texts of bookings can be left out
+func SkipText(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipTextKey).(bool)
+	return skip
+}
+
+// AllowOverlap tells booking services that refuse bookings overlapping with
+// the existing bookings of the user to book anyway
+func AllowOverlap(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowOverlapKey, true)
+}
+
+// OverlapAllowed tells if a booking may overlap with existing bookings
+func OverlapAllowed(ctx context.Context) bool {
+	allow, _ := ctx.Value(allowOverlapKey).(bool)
+	return allow
+}
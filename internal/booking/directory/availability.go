@@ -0,0 +1,71 @@
+package directory
+
+import (
+	"context"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/parallel"
+)
+
+// Number of days that are requested from a provider at the same time when
+// emulating a range query
+const maxConcurrentDays = 4
+
+func (bs *BookingService) AvailableRange(ctx context.Context, start time.Time, end time.Time) ([]booking.DayAvailability, error) {
+	if len(bs.providers) == 0 {
+		return nil, ErrNoServices
+	}
+
+	days, errs := bs.availableRange(ctx, start, end)
+	if len(errs) == len(bs.providers) {
+		return nil, &servicesFailedError{errs: errs}
+	}
+
+	return days, partialError(errs)
+}
+
+func (bs *BookingService) availableRange(ctx context.Context, start time.Time, end time.Time) ([]booking.DayAvailability, []*serviceError) {
+	results, errs := bs.fanOut(ctx, func(ctx context.Context, provider booking.BookingService) (interface{}, error) {
+		return AvailableRange(ctx, provider, start, end)
+	})
+
+	days := booking.Days(start, end)
+	for _, result := range results {
+		for _, d := range result.value.([]booking.DayAvailability) {
+			for i := range days {
+				if days[i].Start.Equal(d.Start) {
+					days[i].Rooms = append(days[i].Rooms, d.Rooms...)
+				}
+			}
+		}
+	}
+	for i := range days {
+		days[i].Rooms = bs.mergeRooms(days[i].Rooms)
+	}
+	return days, errs
+}
+
+// AvailableRange returns the rooms of bs available between the time of day of
+// start and of end on every day from start to end. Services that don't
+// implement booking.RangeService are asked for the available rooms of every
+// day.
+func AvailableRange(ctx context.Context, bs booking.BookingService, start time.Time, end time.Time) ([]booking.DayAvailability, error) {
+	if rs, ok := bs.(booking.RangeService); ok {
+		return rs.AvailableRange(ctx, start, end)
+	}
+	return emulateAvailableRange(ctx, bs, start, end)
+}
+
+func emulateAvailableRange(ctx context.Context, bs booking.BookingService, start time.Time, end time.Time) ([]booking.DayAvailability, error) {
+	days := booking.Days(start, end)
+	err := parallel.Do(ctx, len(days), maxConcurrentDays, func(ctx context.Context, i int) error {
+		var err error
+		days[i].Rooms, err = bs.Available(ctx, days[i].Start, days[i].End)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return days, nil
+}
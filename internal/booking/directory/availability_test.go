@@ -0,0 +1,72 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+	fmtLog "sidus.io/boogrocha/internal/log/fmt"
+)
+
+// rangeService answers range queries natively but fails every other call
+type rangeService struct {
+	errorService
+	rooms []booking.Room
+}
+
+func (rs *rangeService) AvailableRange(ctx context.Context, start time.Time, end time.Time) ([]booking.DayAvailability, error) {
+	days := booking.Days(start, end)
+	for i := range days {
+		days[i].Rooms = rs.rooms
+	}
+	return days, nil
+}
+
+func TestBookingService_AvailableRange(t *testing.T) {
+	start := time.Date(2019, 10, 14, 13, 0, 0, 0, time.UTC)
+	end := time.Date(2019, 10, 15, 15, 0, 0, 0, time.UTC)
+
+	bs := NewBookingService(map[string]booking.BookingService{
+		providerA: newStaticService(nil, []booking.Room{roomAA}),
+		providerB: &errorService{},
+		providerC: &rangeService{rooms: []booking.Room{roomCA}},
+	}, &fmtLog.Logger{})
+
+	got, err := bs.AvailableRange(context.Background(), start, end)
+	if !errors.Is(err, ErrPartialResult) {
+		t.Errorf("BookingService.AvailableRange() error = %v, want %v", err, ErrPartialResult)
+		return
+	}
+
+	want := []booking.DayAvailability{
+		{Start: start, End: start.Add(2 * time.Hour), Rooms: []booking.Room{roomAA, roomCA}},
+		{Start: start.AddDate(0, 0, 1), End: end, Rooms: []booking.Room{roomAA, roomCA}},
+	}
+
+	for _, day := range got {
+		sort.Slice(day.Rooms, func(i, j int) bool {
+			return day.Rooms[i].Provider < day.Rooms[j].Provider
+		})
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BookingService.AvailableRange() = %v, want %v", got, want)
+	}
+}
+
+func TestBookingService_AvailableRangeFailed(t *testing.T) {
+	start := time.Date(2019, 10, 14, 13, 0, 0, 0, time.UTC)
+
+	bs := NewBookingService(map[string]booking.BookingService{
+		providerA: &errorService{},
+	}, &fmtLog.Logger{})
+
+	_, err := bs.AvailableRange(context.Background(), start, start.Add(time.Hour))
+	if err == nil {
+		t.Errorf("BookingService.AvailableRange() expected error when all services fail")
+	}
+}
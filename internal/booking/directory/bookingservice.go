@@ -1,7 +1,10 @@
 package directory
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"sync"
 	"time"
 
@@ -17,173 +20,190 @@ const (
 type BookingService struct {
 	providers map[string]booking.BookingService
 	log       log.Logger
-}
+	opts      Options
 
-func NewBookingService(services map[string]booking.BookingService, log log.Logger) *BookingService {
-	return &BookingService{providers: services, log: log}
+	// alternatives are the rooms of every provider that has been seen with
+	// a room, by the id of the room
+	mutex        sync.Mutex
+	alternatives map[string][]booking.Room
 }
 
-type availableResult struct {
-	available []booking.Room
-	err       *serviceError
+// Options decide how long the providers are waited for when they are all
+// asked at the same time, and whether rooms found at several providers are
+// merged. The zero value waits for every provider and merges nothing.
+type Options struct {
+	// Timeout is how long a provider may take to answer before its results
+	// are left out, 0 means no limit
+	Timeout time.Duration
+	// Timeouts replace Timeout for the providers in it
+	Timeouts map[string]time.Duration
+	// Primary are the providers that are always waited for. Once all of
+	// them have answered the other providers get Grace more to answer
+	// before their results are left out. Every provider is waited for if
+	// none of them is primary.
+	Primary []string
+	Grace   time.Duration
+	// MergeRooms shows rooms with the same id at several providers as one
+	// room, which is booked through the first of the providers in
+	// Preferred that has it available. If that provider refuses the
+	// booking the others are tried.
+	MergeRooms bool
+	Preferred  []string
+	// RefuseOverlaps makes Book refuse bookings overlapping with the
+	// bookings the user already has at any provider, unless allowed with
+	// booking.AllowOverlap. Booking the same room at the same time again
+	// returns the existing booking.
+	RefuseOverlaps bool
 }
 
-type myBookingsResult struct {
-	bookings []booking.Booking
-	err      *serviceError
-}
-
-type serviceError struct {
-	serviceName string
-	err         error
+func NewBookingService(services map[string]booking.BookingService, log log.Logger) *BookingService {
+	return NewBookingServiceWithOptions(services, log, Options{})
 }
 
-func (e *serviceError) Error() string {
-	return fmt.Sprintf("couldn't get available rooms from provider %s: %s", e.serviceName, e.err.Error())
+func NewBookingServiceWithOptions(services map[string]booking.BookingService, log log.Logger, opts Options) *BookingService {
+	return &BookingService{
+		providers:    services,
+		log:          log,
+		opts:         opts,
+		alternatives: make(map[string][]booking.Room),
+	}
 }
 
-func (bs *BookingService) Book(b booking.Booking) error {
+func (bs *BookingService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
 	if len(bs.providers) == 0 {
 		err := ErrNoServices
 		bs.log.Error(err.Error())
-		return err
+		return booking.Booking{}, err
+	}
+
+	p := b.Room.Provider
+	if bs.providers[p] == nil {
+		return booking.Booking{}, fmt.Errorf("%w: %s", ErrNoSuchProvider, p)
+	}
+
+	if bs.opts.RefuseOverlaps {
+		existing, found, err := CheckOverlap(ctx, bs, b)
+		if err != nil || found {
+			return existing, err
+		}
+	}
+
+	var errs []*serviceError
+	alternatives := bs.Alternatives(b.Room)
+	for i := 0; i < len(alternatives); i++ {
+		room := alternatives[i]
+		b.Room = room
+		created, err := bs.providers[room.Provider].Book(ctx, b)
+		if err == nil {
+			return created, nil
+		}
+		errs = append(errs, &serviceError{serviceName: room.Provider, err: err})
+		// The booking might have been made if it failed in any other way
+		if !refused(err) {
+			break
+		}
+		if i == 0 && len(alternatives) == 1 {
+			alternatives = bs.findAlternatives(ctx, b)
+		}
+	}
+
+	if len(errs) == 1 {
+		return booking.Booking{}, errs[0]
 	}
+	return booking.Booking{}, &servicesFailedError{errs: errs}
+}
 
+// BookingForm returns what the provider of the room would be sent to book
+// it, or nil if the provider can't tell
+func (bs *BookingService) BookingForm(b booking.Booking) (url.Values, error) {
 	p := b.Room.Provider
 	if bs.providers[p] == nil {
-		return fmt.Errorf("booking service not found: %s", p)
+		return nil, fmt.Errorf("%w: %s", ErrNoSuchProvider, p)
+	}
+	fs, ok := bs.providers[p].(booking.FormService)
+	if !ok {
+		return nil, nil
 	}
+	return fs.BookingForm(b)
+}
 
-	return bs.providers[p].Book(b)
+// UnBookingRequest returns what the provider of the booking would be sent to
+// remove it, or nil if the provider can't tell
+func (bs *BookingService) UnBookingRequest(ctx context.Context, b booking.Booking) (*http.Request, error) {
+	p := b.Room.Provider
+	if bs.providers[p] == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSuchProvider, p)
+	}
+	fs, ok := bs.providers[p].(booking.FormService)
+	if !ok {
+		return nil, nil
+	}
+	return fs.UnBookingRequest(ctx, b)
 }
 
-func (bs *BookingService) UnBook(b booking.Booking) error {
+func (bs *BookingService) UnBook(ctx context.Context, b booking.Booking) error {
 	if len(bs.providers) == 0 {
 		return ErrNoServices
 	}
 
 	p := b.Room.Provider
 	if bs.providers[p] == nil {
-		return fmt.Errorf("booking provider not found: %s", p)
+		return fmt.Errorf("%w: %s", ErrNoSuchProvider, p)
 	}
 
-	return bs.providers[p].UnBook(b)
+	err := bs.providers[p].UnBook(ctx, b)
+	if err != nil {
+		return &serviceError{serviceName: p, err: err}
+	}
+	return nil
 }
 
-func (bs *BookingService) MyBookings() ([]booking.Booking, error) {
+func (bs *BookingService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
 	if len(bs.providers) == 0 {
 		return nil, ErrNoServices
 	}
 
-	rooms, errs := bs.myBookings()
-	for _, err := range errs {
-		bs.log.Error(err.Error())
-	}
-
+	rooms, errs := bs.myBookings(ctx)
 	if len(errs) == len(bs.providers) {
-		return nil, ErrAllServicesFailed
+		return nil, &servicesFailedError{errs: errs}
 	}
 
-	return rooms, nil
+	return rooms, partialError(errs)
 }
 
-func (bs *BookingService) myBookings() ([]booking.Booking, []*serviceError) {
-	incoming := make(chan myBookingsResult)
-
-	wg := sync.WaitGroup{}
-	go func() {
-		wg.Wait()
-		close(incoming)
-	}()
-
-	for name, provider := range bs.providers {
-		wg.Add(1)
-		go func(name string, provider booking.BookingService) {
-			bookings, err := provider.MyBookings()
-			if err != nil {
-				incoming <- myBookingsResult{
-					bookings: nil,
-					err: &serviceError{
-						serviceName: name,
-						err:         err,
-					},
-				}
-				return
-			}
-			incoming <- myBookingsResult{
-				bookings: bookings,
-				err:      nil,
-			}
-		}(name, provider)
-	}
+func (bs *BookingService) myBookings(ctx context.Context) ([]booking.Booking, []*serviceError) {
+	results, errs := bs.fanOut(ctx, func(ctx context.Context, provider booking.BookingService) (interface{}, error) {
+		return provider.MyBookings(ctx)
+	})
 
 	var bookings []booking.Booking
-	var errors []*serviceError
-	for result := range incoming {
-		wg.Done()
-		if result.err != nil {
-			errors = append(errors, result.err)
-		}
-		bookings = append(bookings, result.bookings...)
+	for _, result := range results {
+		bookings = append(bookings, result.value.([]booking.Booking)...)
 	}
-	return bookings, errors
+	return bookings, errs
 }
 
-func (bs *BookingService) Available(start time.Time, end time.Time) ([]booking.Room, error) {
+func (bs *BookingService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
 	if len(bs.providers) == 0 {
 		return nil, ErrNoServices
 	}
 
-	rooms, errs := bs.available(start, end)
-	for _, err := range errs {
-		bs.log.Error(err.Error())
-	}
-
+	rooms, errs := bs.available(ctx, start, end)
 	if len(errs) == len(bs.providers) {
-		return nil, ErrAllServicesFailed
+		return nil, &servicesFailedError{errs: errs}
 	}
 
-	return rooms, nil
+	return rooms, partialError(errs)
 }
 
-func (bs *BookingService) available(start time.Time, end time.Time) ([]booking.Room, []*serviceError) {
-	incoming := make(chan availableResult)
-
-	wg := sync.WaitGroup{}
-	go func() {
-		wg.Wait()
-		close(incoming)
-	}()
-
-	for name, provider := range bs.providers {
-		wg.Add(1)
-		go func(name string, provider booking.BookingService) {
-			a, err := provider.Available(start, end)
-			if err != nil {
-				incoming <- availableResult{
-					available: nil,
-					err: &serviceError{
-						serviceName: name,
-						err:         err,
-					},
-				}
-				return
-			}
-			incoming <- availableResult{
-				available: a,
-				err:       nil,
-			}
-		}(name, provider)
-	}
+func (bs *BookingService) available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, []*serviceError) {
+	results, errs := bs.fanOut(ctx, func(ctx context.Context, provider booking.BookingService) (interface{}, error) {
+		return provider.Available(ctx, start, end)
+	})
 
 	var rooms []booking.Room
-	var errors []*serviceError
-	for result := range incoming {
-		wg.Done()
-		if result.err != nil {
-			errors = append(errors, result.err)
-		}
-		rooms = append(rooms, result.available...)
+	for _, result := range results {
+		rooms = append(rooms, result.value.([]booking.Room)...)
 	}
-	return rooms, errors
+	return bs.mergeRooms(rooms), errs
 }
@@ -1,6 +1,8 @@
 package directory
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
@@ -77,8 +79,8 @@ func TestBookingService_Book(t *testing.T) {
 			name: "only failing services",
 			fields: fields{
 				services: map[string]booking.BookingService{
-					providerA: &booking.MockErrorService{},
-					providerB: &booking.MockErrorService{},
+					providerA: &errorService{},
+					providerB: &errorService{},
 				},
 				log: &fmtLog.Logger{},
 			},
@@ -96,7 +98,7 @@ func TestBookingService_Book(t *testing.T) {
 			name: "invalid prefix",
 			fields: fields{
 				services: map[string]booking.BookingService{
-					providerA: booking.NewMockService([]booking.Room{
+					providerA: newMockService([]booking.Room{
 						{
 							Provider: providerA,
 							Id:       roomA,
@@ -110,8 +112,8 @@ func TestBookingService_Book(t *testing.T) {
 							Id:       roomC,
 						},
 					}),
-					providerB: &booking.MockErrorService{},
-					providerC: booking.NewMockService([]booking.Room{
+					providerB: &errorService{},
+					providerC: newMockService([]booking.Room{
 						{
 							Provider: providerC,
 							Id:       roomA,
@@ -138,7 +140,7 @@ func TestBookingService_Book(t *testing.T) {
 			name: "already booked",
 			fields: fields{
 				services: map[string]booking.BookingService{
-					providerA: &booking.MockService{
+					providerA: &mockService{
 						Bookings: map[booking.Room]*booking.Booking{
 							roomAA: {
 								Room: roomAA,
@@ -149,8 +151,8 @@ func TestBookingService_Book(t *testing.T) {
 							roomAB,
 							roomAC,
 						}},
-					providerB: &booking.MockErrorService{},
-					providerC: booking.NewMockService([]booking.Room{
+					providerB: &errorService{},
+					providerC: newMockService([]booking.Room{
 						roomCA, roomCB,
 					}),
 				},
@@ -167,9 +169,9 @@ func TestBookingService_Book(t *testing.T) {
 			name: "successfully book",
 			fields: fields{
 				services: map[string]booking.BookingService{
-					providerA: booking.NewMockService([]booking.Room{roomAA, roomAB, roomAC}),
-					providerB: &booking.MockErrorService{},
-					providerC: booking.NewMockService([]booking.Room{roomCA, roomCB}),
+					providerA: newMockService([]booking.Room{roomAA, roomAB, roomAC}),
+					providerB: &errorService{},
+					providerC: newMockService([]booking.Room{roomCA, roomCB}),
 				},
 				log: &fmtLog.Logger{},
 			},
@@ -187,9 +189,13 @@ func TestBookingService_Book(t *testing.T) {
 				providers: tt.fields.services,
 				log:       tt.fields.log,
 			}
-			if err := bs.Book(tt.args.booking); (err != nil) != tt.wantErr {
+			created, err := bs.Book(context.Background(), tt.args.booking)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("BookingService.Book() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if err == nil && created.Room != tt.args.booking.Room {
+				t.Errorf("BookingService.Book() booked %v, want %v", created.Room, tt.args.booking.Room)
+			}
 		})
 	}
 }
@@ -222,8 +228,8 @@ func TestBookingService_UnBook(t *testing.T) {
 			name: "only failing services",
 			fields: fields{
 				services: map[string]booking.BookingService{
-					providerA: &booking.MockErrorService{},
-					providerB: &booking.MockErrorService{},
+					providerA: &errorService{},
+					providerB: &errorService{},
 				},
 				log: &fmtLog.Logger{},
 			},
@@ -238,9 +244,9 @@ func TestBookingService_UnBook(t *testing.T) {
 			name: "invalid prefix",
 			fields: fields{
 				services: map[string]booking.BookingService{
-					providerA: booking.NewMockService([]booking.Room{roomAA, roomAB, roomAC}),
-					providerB: &booking.MockErrorService{},
-					providerC: booking.NewMockService([]booking.Room{roomCA, roomCB}),
+					providerA: newMockService([]booking.Room{roomAA, roomAB, roomAC}),
+					providerB: &errorService{},
+					providerC: newMockService([]booking.Room{roomCA, roomCB}),
 				},
 				log: &fmtLog.Logger{},
 			},
@@ -255,7 +261,7 @@ func TestBookingService_UnBook(t *testing.T) {
 			name: "successfully unbook",
 			fields: fields{
 				services: map[string]booking.BookingService{
-					providerA: &booking.MockService{Bookings: map[booking.Room]*booking.Booking{
+					providerA: &mockService{Bookings: map[booking.Room]*booking.Booking{
 						roomAA: {
 							Room: roomAA,
 						}},
@@ -265,8 +271,8 @@ func TestBookingService_UnBook(t *testing.T) {
 							roomAC,
 						},
 					},
-					providerB: &booking.MockErrorService{},
-					providerC: booking.NewMockService([]booking.Room{roomCA, roomCB}),
+					providerB: &errorService{},
+					providerC: newMockService([]booking.Room{roomCA, roomCB}),
 				},
 				log: &fmtLog.Logger{},
 			},
@@ -281,9 +287,9 @@ func TestBookingService_UnBook(t *testing.T) {
 			name: "not booked",
 			fields: fields{
 				services: map[string]booking.BookingService{
-					providerA: booking.NewMockService([]booking.Room{roomAA, roomAB, roomAC}),
-					providerB: &booking.MockErrorService{},
-					providerC: booking.NewMockService([]booking.Room{roomCA, roomCB}),
+					providerA: newMockService([]booking.Room{roomAA, roomAB, roomAC}),
+					providerB: &errorService{},
+					providerC: newMockService([]booking.Room{roomCA, roomCB}),
 				},
 				log: &fmtLog.Logger{},
 			},
@@ -301,7 +307,7 @@ func TestBookingService_UnBook(t *testing.T) {
 				providers: tt.fields.services,
 				log:       tt.fields.log,
 			}
-			if err := b.UnBook(tt.args.booking); (err != nil) != tt.wantErr {
+			if err := b.UnBook(context.Background(), tt.args.booking); (err != nil) != tt.wantErr {
 				t.Errorf("BookingService.UnBook() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -331,8 +337,8 @@ func TestBookingService_MyBookings(t *testing.T) {
 			name: "only failing services",
 			fields: fields{
 				services: map[string]booking.BookingService{
-					providerA: &booking.MockErrorService{},
-					providerB: &booking.MockErrorService{},
+					providerA: &errorService{},
+					providerB: &errorService{},
 				},
 				log: &fmtLog.Logger{},
 			},
@@ -343,7 +349,7 @@ func TestBookingService_MyBookings(t *testing.T) {
 			name: "some failing services",
 			fields: fields{
 				services: map[string]booking.BookingService{
-					providerA: booking.NewMockStaticService([]booking.Booking{
+					providerA: newStaticService([]booking.Booking{
 						{
 							Room: roomAA,
 						},
@@ -354,8 +360,8 @@ func TestBookingService_MyBookings(t *testing.T) {
 							Room: roomAC,
 						},
 					}, nil),
-					providerB: &booking.MockErrorService{},
-					providerC: booking.NewMockStaticService([]booking.Booking{
+					providerB: &errorService{},
+					providerC: newStaticService([]booking.Booking{
 						{
 							Room: roomCA,
 						},
@@ -383,7 +389,7 @@ func TestBookingService_MyBookings(t *testing.T) {
 					Room: roomCB,
 				},
 			},
-			wantErr: false,
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
@@ -392,7 +398,7 @@ func TestBookingService_MyBookings(t *testing.T) {
 				providers: tt.fields.services,
 				log:       tt.fields.log,
 			}
-			got, err := b.MyBookings()
+			got, err := b.MyBookings(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BookingService.MyBookings() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -426,7 +432,7 @@ func TestBookingService_myBookings(t *testing.T) {
 	for i := 3; i >= 0; i-- {
 		providerName := fmt.Sprintf("service%d", i)
 		if i%7 == 0 {
-			services[providerName] = &booking.MockErrorService{}
+			services[providerName] = &errorService{}
 			nErrors += 1
 		} else {
 			var bookings []booking.Booking
@@ -448,13 +454,13 @@ func TestBookingService_myBookings(t *testing.T) {
 					Id: fmt.Sprintf(prefixFormat, providerName, id),
 				})
 			}
-			services[providerName] = booking.NewMockStaticService(bookings, nil)
+			services[providerName] = newStaticService(bookings, nil)
 		}
 	}
 
 	bs := NewBookingService(services, &fmtLog.Logger{})
 
-	bookings, errors := bs.myBookings()
+	bookings, errors := bs.myBookings(context.Background())
 
 	if len(errors) != nErrors {
 		t.Errorf("BookingService.availabe() len(errors) = %d, nErrors %d", len(errors), nErrors)
@@ -503,8 +509,8 @@ func TestBookingService_Available(t *testing.T) {
 			name: "only failing services",
 			fields: fields{
 				services: map[string]booking.BookingService{
-					providerA: &booking.MockErrorService{},
-					providerB: &booking.MockErrorService{},
+					providerA: &errorService{},
+					providerB: &errorService{},
 				},
 				log: &fmtLog.Logger{},
 			},
@@ -516,9 +522,9 @@ func TestBookingService_Available(t *testing.T) {
 			name: "some failing services",
 			fields: fields{
 				services: map[string]booking.BookingService{
-					providerA: booking.NewMockStaticService(nil, []booking.Room{roomAA}),
-					providerB: &booking.MockErrorService{},
-					providerC: booking.NewMockStaticService(nil, []booking.Room{roomCA, roomCB}),
+					providerA: newStaticService(nil, []booking.Room{roomAA}),
+					providerB: &errorService{},
+					providerC: newStaticService(nil, []booking.Room{roomCA, roomCB}),
 				},
 				log: &fmtLog.Logger{},
 			},
@@ -527,7 +533,7 @@ func TestBookingService_Available(t *testing.T) {
 				end:   time.Time{},
 			},
 			want:    []booking.Room{roomAA, roomCA, roomCB},
-			wantErr: false,
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
@@ -536,7 +542,7 @@ func TestBookingService_Available(t *testing.T) {
 				providers: tt.fields.services,
 				log:       tt.fields.log,
 			}
-			got, err := bs.Available(tt.args.start, tt.args.end)
+			got, err := bs.Available(context.Background(), tt.args.start, tt.args.end)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BookingService.Available() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -561,7 +567,7 @@ func TestBookingService_available(t *testing.T) {
 	for i := 100; i >= 0; i-- {
 		providerName := fmt.Sprintf("service%d", i)
 		if i%7 == 0 {
-			services[providerName] = &booking.MockErrorService{}
+			services[providerName] = &errorService{}
 			nErrors += 1
 		} else {
 			var rooms []booking.Room
@@ -574,13 +580,13 @@ func TestBookingService_available(t *testing.T) {
 				rooms = append(rooms, room)
 				result = append(result, room)
 			}
-			services[providerName] = booking.NewMockStaticService(nil, rooms)
+			services[providerName] = newStaticService(nil, rooms)
 		}
 	}
 
 	bs := NewBookingService(services, &fmtLog.Logger{})
 
-	rooms, errors := bs.available(time.Time{}, time.Time{})
+	rooms, errors := bs.available(context.Background(), time.Time{}, time.Time{})
 
 	if len(errors) != nErrors {
 		t.Errorf("BookingService.availabe() len(errors) = %d, nErrors %d", len(errors), nErrors)
@@ -605,3 +611,220 @@ func TestBookingService_available(t *testing.T) {
 		t.Errorf("BookingService.available() = %v, wantRooms %v", rooms, result)
 	}
 }
+
+func TestBookingService_Errors(t *testing.T) {
+	bs := NewBookingService(map[string]booking.BookingService{
+		providerA: &errorService{err: booking.ErrAuthenticationFailed},
+		providerB: &errorService{err: booking.Wrap(booking.ErrProviderUnreachable, fmt.Errorf("timeout"))},
+		providerC: &mockService{
+			Bookings: map[booking.Room]*booking.Booking{
+				roomCA: {
+					Room: roomCA,
+				},
+			},
+			Rooms: []booking.Room{roomCA},
+		},
+	}, &fmtLog.Logger{})
+
+	_, err := bs.Book(context.Background(), booking.Booking{Room: roomAA})
+	if !errors.Is(err, booking.ErrAuthenticationFailed) {
+		t.Errorf("BookingService.Book() error = %v, want %v", err, booking.ErrAuthenticationFailed)
+	}
+
+	_, err = bs.Book(context.Background(), booking.Booking{Room: roomCA})
+	if !errors.Is(err, booking.ErrRoomUnavailable) {
+		t.Errorf("BookingService.Book() error = %v, want %v", err, booking.ErrRoomUnavailable)
+	}
+
+	_, err = bs.Book(context.Background(), booking.Booking{Room: roomXA})
+	if !errors.Is(err, ErrNoSuchProvider) {
+		t.Errorf("BookingService.Book() error = %v, want %v", err, ErrNoSuchProvider)
+	}
+
+	_, err = bs.Available(context.Background(), time.Time{}, time.Time{})
+	var partial *PartialError
+	if !errors.As(err, &partial) || !reflect.DeepEqual(partial.Missing(), []string{providerA, providerB}) {
+		t.Errorf("BookingService.Available() error = %v, want results from %s and %s missing", err, providerA, providerB)
+	}
+	if !errors.Is(err, booking.ErrAuthenticationFailed) || !errors.Is(partial.Errors()[providerB], booking.ErrProviderUnreachable) {
+		t.Errorf("BookingService.Available() error = %v, want the errors of the providers", err)
+	}
+
+	delete(bs.providers, providerC)
+	_, err = bs.Available(context.Background(), time.Time{}, time.Time{})
+	for _, want := range []error{ErrAllServicesFailed, booking.ErrAuthenticationFailed, booking.ErrProviderUnreachable} {
+		if !errors.Is(err, want) {
+			t.Errorf("BookingService.Available() error = %v, want %v", err, want)
+		}
+	}
+}
+
+// slowService answers like service but only after delay. Unless stubborn it
+// gives up when the context is done.
+type slowService struct {
+	booking.BookingService
+	delay    time.Duration
+	stubborn bool
+}
+
+func (s *slowService) wait(ctx context.Context) error {
+	if s.stubborn {
+		time.Sleep(s.delay)
+		return nil
+	}
+	select {
+	case <-time.After(s.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *slowService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.BookingService.MyBookings(ctx)
+}
+
+func (s *slowService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.BookingService.Available(ctx, start, end)
+}
+
+func TestBookingService_AvailableDeadlines(t *testing.T) {
+	fast := newStaticService(nil, []booking.Room{roomAA})
+	slow := func(delay time.Duration) booking.BookingService {
+		return &slowService{BookingService: newStaticService(nil, []booking.Room{roomCA}), delay: delay}
+	}
+	stubborn := func(delay time.Duration) booking.BookingService {
+		return &slowService{BookingService: newStaticService(nil, []booking.Room{roomCA}), delay: delay, stubborn: true}
+	}
+
+	tests := []struct {
+		name     string
+		services map[string]booking.BookingService
+		opts     Options
+		want     []booking.Room
+		wantErr  error
+		// The call should return before this
+		maxDuration time.Duration
+	}{
+		{
+			name:     "no limits",
+			services: map[string]booking.BookingService{providerA: fast, providerC: slow(50 * time.Millisecond)},
+			want:     []booking.Room{roomAA, roomCA},
+		},
+		{
+			name:        "slow provider",
+			services:    map[string]booking.BookingService{providerA: fast, providerC: slow(time.Second)},
+			opts:        Options{Timeout: 20 * time.Millisecond},
+			want:        []booking.Room{roomAA},
+			wantErr:     ErrProviderTimeout,
+			maxDuration: 500 * time.Millisecond,
+		},
+		{
+			name:        "provider ignoring the deadline",
+			services:    map[string]booking.BookingService{providerA: fast, providerC: stubborn(time.Second)},
+			opts:        Options{Timeout: 20 * time.Millisecond},
+			want:        []booking.Room{roomAA},
+			wantErr:     booking.ErrProviderUnreachable,
+			maxDuration: 500 * time.Millisecond,
+		},
+		{
+			name:     "longer timeout for provider",
+			services: map[string]booking.BookingService{providerA: fast, providerC: slow(50 * time.Millisecond)},
+			opts:     Options{Timeout: 20 * time.Millisecond, Timeouts: map[string]time.Duration{providerC: time.Second}},
+			want:     []booking.Room{roomAA, roomCA},
+		},
+		{
+			name:        "every provider slow",
+			services:    map[string]booking.BookingService{providerA: slow(time.Second), providerC: slow(time.Second)},
+			opts:        Options{Timeout: 20 * time.Millisecond},
+			wantErr:     ErrAllServicesFailed,
+			maxDuration: 500 * time.Millisecond,
+		},
+		{
+			name:        "primary answered",
+			services:    map[string]booking.BookingService{providerA: fast, providerC: slow(time.Second)},
+			opts:        Options{Primary: []string{providerA}},
+			want:        []booking.Room{roomAA},
+			wantErr:     ErrProviderSkipped,
+			maxDuration: 500 * time.Millisecond,
+		},
+		{
+			name:     "answered within grace",
+			services: map[string]booking.BookingService{providerA: fast, providerC: slow(20 * time.Millisecond)},
+			opts:     Options{Primary: []string{providerA}, Grace: time.Second},
+			want:     []booking.Room{roomAA, roomCA},
+		},
+		{
+			name:     "slow primary",
+			services: map[string]booking.BookingService{providerA: fast, providerC: slow(50 * time.Millisecond)},
+			opts:     Options{Primary: []string{providerC}},
+			want:     []booking.Room{roomAA, roomCA},
+		},
+		{
+			name:        "primary timed out",
+			services:    map[string]booking.BookingService{providerA: fast, providerC: slow(time.Second)},
+			opts:        Options{Primary: []string{providerC}, Timeout: 20 * time.Millisecond},
+			want:        []booking.Room{roomAA},
+			wantErr:     ErrProviderTimeout,
+			maxDuration: 500 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := NewBookingServiceWithOptions(tt.services, &fmtLog.Logger{}, tt.opts)
+
+			started := time.Now()
+			got, err := bs.Available(context.Background(), time.Time{}, time.Time{})
+			if tt.maxDuration > 0 && time.Since(started) > tt.maxDuration {
+				t.Errorf("BookingService.Available() took %s, want less than %s", time.Since(started), tt.maxDuration)
+			}
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("BookingService.Available() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			sort.Slice(got, func(i, j int) bool {
+				return got[i].Provider < got[j].Provider
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BookingService.Available() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookingService_MyBookingsDeadlines(t *testing.T) {
+	bs := NewBookingServiceWithOptions(map[string]booking.BookingService{
+		providerA: newStaticService([]booking.Booking{{Room: roomAA}}, nil),
+		providerC: &slowService{BookingService: newStaticService([]booking.Booking{{Room: roomCA}}, nil), delay: time.Second},
+	}, &fmtLog.Logger{}, Options{Timeout: 20 * time.Millisecond})
+
+	got, err := bs.MyBookings(context.Background())
+	var partial *PartialError
+	if !errors.As(err, &partial) || !reflect.DeepEqual(partial.Missing(), []string{providerC}) {
+		t.Errorf("BookingService.MyBookings() error = %v, want results from %s missing", err, providerC)
+	}
+	if !reflect.DeepEqual(got, []booking.Booking{{Room: roomAA}}) {
+		t.Errorf("BookingService.MyBookings() = %v, want %v", got, []booking.Booking{{Room: roomAA}})
+	}
+}
+
+func TestBookingService_AvailableCanceled(t *testing.T) {
+	bs := NewBookingServiceWithOptions(map[string]booking.BookingService{
+		providerA: &slowService{BookingService: newStaticService(nil, nil), delay: time.Second},
+		providerC: &slowService{BookingService: newStaticService(nil, nil), delay: time.Second},
+	}, &fmtLog.Logger{}, Options{Timeout: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := bs.Available(ctx, time.Time{}, time.Time{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("BookingService.Available() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
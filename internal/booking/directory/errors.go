@@ -1,5 +1,12 @@
 package directory
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
 type Error string
 
 func (e Error) Error() string {
@@ -9,4 +16,138 @@ func (e Error) Error() string {
 const (
 	ErrNoServices        = Error("no booking services")
 	ErrAllServicesFailed = Error("all booking services failed")
+	ErrPartialResult     = Error("results from some booking services missing")
+	ErrNoSuchProvider    = Error("booking provider not found")
+	ErrProviderTimeout   = Error("no answer")
+	ErrProviderSkipped   = Error("booking provider skipped as the primary providers answered first")
 )
+
+type serviceError struct {
+	serviceName string
+	err         error
+}
+
+func (e *serviceError) Error() string {
+	return fmt.Sprintf("provider %s: %s", e.serviceName, e.err.Error())
+}
+
+func (e *serviceError) Unwrap() error {
+	return e.err
+}
+
+// serviceErrors are the errors of several providers
+type serviceErrors []*serviceError
+
+func (errs serviceErrors) String() string {
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, ", ")
+}
+
+func (errs serviceErrors) is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (errs serviceErrors) as(target interface{}) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// servicesFailedError is returned when every provider failed. It matches
+// ErrAllServicesFailed as well as the errors of the individual providers.
+type servicesFailedError struct {
+	errs serviceErrors
+}
+
+func (e *servicesFailedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrAllServicesFailed, e.errs)
+}
+
+func (e *servicesFailedError) Is(target error) bool {
+	return target == ErrAllServicesFailed || e.errs.is(target)
+}
+
+func (e *servicesFailedError) As(target interface{}) bool {
+	return e.errs.as(target)
+}
+
+// PartialError is returned together with the results of the providers that
+// answered when some, but not all, providers failed. It matches
+// ErrPartialResult as well as the errors of the providers that failed.
+type PartialError struct {
+	errs serviceErrors
+}
+
+// partialError returns a PartialError for errs, or nil if there are none
+func partialError(errs []*serviceError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &PartialError{errs: errs}
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("results from %s missing: %s", strings.Join(e.Missing(), ", "), e.errs)
+}
+
+func (e *PartialError) Is(target error) bool {
+	return target == ErrPartialResult || e.errs.is(target)
+}
+
+func (e *PartialError) As(target interface{}) bool {
+	return e.errs.as(target)
+}
+
+// Missing returns the names of the providers whose results are missing
+func (e *PartialError) Missing() []string {
+	var providers []string
+	for _, err := range e.errs {
+		providers = append(providers, err.serviceName)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// Errors returns why the results of each of the missing providers are missing
+func (e *PartialError) Errors() map[string]error {
+	errs := make(map[string]error, len(e.errs))
+	for _, err := range e.errs {
+		errs[err.serviceName] = err.err
+	}
+	return errs
+}
+
+// Merge returns the union of the missing providers of e and other, keeping
+// the first error of every provider. Either may be nil.
+func (e *PartialError) Merge(other *PartialError) *PartialError {
+	if e == nil {
+		return other
+	}
+	if other == nil {
+		return e
+	}
+
+	merged := &PartialError{errs: append(serviceErrors{}, e.errs...)}
+	seen := make(map[string]bool)
+	for _, err := range e.errs {
+		seen[err.serviceName] = true
+	}
+	for _, err := range other.errs {
+		if !seen[err.serviceName] {
+			seen[err.serviceName] = true
+			merged.errs = append(merged.errs, err)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,130 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+type providerCall func(ctx context.Context, provider booking.BookingService) (interface{}, error)
+
+type providerResult struct {
+	name  string
+	value interface{}
+	err   error
+}
+
+// fanOut calls call for every provider at the same time and returns the
+// results of the providers that answered and the errors of those that
+// didn't. A provider that doesn't answer within its timeout, or within the
+// grace period after the primary providers have answered, fails with
+// ErrProviderTimeout or ErrProviderSkipped without being waited for.
+func (bs *BookingService) fanOut(ctx context.Context, call providerCall) ([]providerResult, []*serviceError) {
+	incoming := make(chan providerResult)
+
+	cancels := make(map[string]context.CancelFunc, len(bs.providers))
+	for name, provider := range bs.providers {
+		var providerCtx context.Context
+		var cancel context.CancelFunc
+		if timeout := bs.timeout(name); timeout > 0 {
+			providerCtx, cancel = context.WithTimeout(ctx, timeout)
+		} else {
+			providerCtx, cancel = context.WithCancel(ctx)
+		}
+		cancels[name] = cancel
+		defer cancel()
+
+		go func(providerCtx context.Context, name string, provider booking.BookingService) {
+			incoming <- bs.call(ctx, providerCtx, name, provider, call)
+		}(providerCtx, name, provider)
+	}
+
+	primary := 0
+	for name := range bs.providers {
+		if bs.isPrimary(name) {
+			primary++
+		}
+	}
+
+	var results []providerResult
+	var errs []*serviceError
+	answered := make(map[string]bool, len(bs.providers))
+	var grace <-chan time.Time
+	for len(answered) < len(bs.providers) {
+		select {
+		case result := <-incoming:
+			answered[result.name] = true
+			if result.err != nil {
+				errs = append(errs, &serviceError{serviceName: result.name, err: result.err})
+			} else {
+				results = append(results, result)
+			}
+
+			if bs.isPrimary(result.name) {
+				primary--
+				if primary == 0 {
+					grace = time.After(bs.opts.Grace)
+				}
+			}
+		case <-grace:
+			grace = nil
+			for name, cancel := range cancels {
+				if !answered[name] {
+					cancel()
+				}
+			}
+		}
+	}
+	return results, errs
+}
+
+// call returns the result of calling the provider, or an error as soon as
+// providerCtx is done even if the provider keeps going
+func (bs *BookingService) call(ctx context.Context, providerCtx context.Context, name string, provider booking.BookingService, call providerCall) providerResult {
+	done := make(chan providerResult, 1)
+	go func() {
+		value, err := call(providerCtx, provider)
+		done <- providerResult{name: name, value: value, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		// A provider giving up because its time ran out is reported the
+		// same way as one that is still going
+		if result.err == nil || providerCtx.Err() == nil {
+			return result
+		}
+	case <-providerCtx.Done():
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		return providerResult{name: name, err: ctx.Err()}
+	case errors.Is(providerCtx.Err(), context.DeadlineExceeded):
+		err := fmt.Errorf("%w within %s", ErrProviderTimeout, bs.timeout(name))
+		return providerResult{name: name, err: booking.Wrap(booking.ErrProviderUnreachable, err)}
+	default:
+		return providerResult{name: name, err: ErrProviderSkipped}
+	}
+}
+
+// timeout returns how long the provider may take to answer, 0 if there is
+// no limit
+func (bs *BookingService) timeout(name string) time.Duration {
+	if timeout, ok := bs.opts.Timeouts[name]; ok {
+		return timeout
+	}
+	return bs.opts.Timeout
+}
+
+func (bs *BookingService) isPrimary(name string) bool {
+	for _, primary := range bs.opts.Primary {
+		if primary == name {
+			return true
+		}
+	}
+	return false
+}
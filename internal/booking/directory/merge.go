@@ -0,0 +1,158 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+// Alternatives returns the room of every provider that the room can be
+// booked through, starting with the room itself followed by the others in
+// order of preference. Only rooms that have been seen at the providers are
+// known, and only if rooms are merged.
+func (bs *BookingService) Alternatives(room booking.Room) []booking.Room {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	alternatives := []booking.Room{room}
+	for _, r := range bs.alternatives[room.Id] {
+		if r.Provider != room.Provider {
+			alternatives = append(alternatives, r)
+		}
+	}
+	return alternatives
+}
+
+// findAlternatives asks the providers which rooms are available during b,
+// for when the room of b wasn't seen by this service. That is the case when
+// the rooms were found by an earlier run, or by a cache in front of it.
+func (bs *BookingService) findAlternatives(ctx context.Context, b booking.Booking) []booking.Room {
+	if !bs.opts.MergeRooms || len(bs.providers) < 2 {
+		return []booking.Room{b.Room}
+	}
+	bs.available(ctx, b.Start, b.End)
+	return bs.Alternatives(b.Room)
+}
+
+// mergeRooms replaces the rooms with the same id from several providers
+// with the room of the most preferred of them, and remembers the others as
+// alternatives to book the room through
+func (bs *BookingService) mergeRooms(rooms []booking.Room) []booking.Room {
+	if !bs.opts.MergeRooms {
+		return rooms
+	}
+	bs.remember(rooms)
+
+	var merged []booking.Room
+	index := make(map[string]int)
+	for _, room := range rooms {
+		i, ok := index[room.Id]
+		if !ok {
+			index[room.Id] = len(merged)
+			merged = append(merged, room)
+			continue
+		}
+		merged[i] = bs.preferredRoom(merged[i], room)
+	}
+	return merged
+}
+
+// mergeTimelines merges the timelines of the same room at several providers
+// into one, where the room is free whenever it's free at any of them
+func (bs *BookingService) mergeTimelines(timelines []booking.Timeline) []booking.Timeline {
+	if !bs.opts.MergeRooms {
+		return timelines
+	}
+	rooms := make([]booking.Room, len(timelines))
+	for i, t := range timelines {
+		rooms[i] = t.Room
+	}
+	bs.remember(rooms)
+
+	var merged []booking.Timeline
+	index := make(map[string]int)
+	for _, t := range timelines {
+		i, ok := index[t.Room.Id]
+		if !ok {
+			index[t.Room.Id] = len(merged)
+			t.Free = append([]bool(nil), t.Free...)
+			merged = append(merged, t)
+			continue
+		}
+		merged[i].Room = bs.preferredRoom(merged[i].Room, t.Room)
+		for j := range merged[i].Free {
+			merged[i].Free[j] = merged[i].Free[j] || j < len(t.Free) && t.Free[j]
+		}
+	}
+	return merged
+}
+
+// preferredRoom returns the room of the most preferred provider, with the
+// details it lacks taken from the other room
+func (bs *BookingService) preferredRoom(a booking.Room, b booking.Room) booking.Room {
+	if bs.prefers(b.Provider, a.Provider) {
+		a, b = b, a
+	}
+	if a.Seats == 0 {
+		a.Seats = b.Seats
+	}
+	if a.Campus == "" {
+		a.Campus = b.Campus
+	}
+	return a
+}
+
+// prefers reports whether provider a is preferred over b, by the order of
+// Preferred and then by name
+func (bs *BookingService) prefers(a string, b string) bool {
+	rankA, rankB := len(bs.opts.Preferred), len(bs.opts.Preferred)
+	for i, p := range bs.opts.Preferred {
+		if p == a {
+			rankA = i
+		}
+		if p == b {
+			rankB = i
+		}
+	}
+	if rankA != rankB {
+		return rankA < rankB
+	}
+	return a < b
+}
+
+func (bs *BookingService) remember(rooms []booking.Room) {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	for _, room := range rooms {
+		known := false
+		for _, r := range bs.alternatives[room.Id] {
+			known = known || r.Provider == room.Provider
+		}
+		if !known {
+			alternatives := append(bs.alternatives[room.Id], room)
+			sort.Slice(alternatives, func(i, j int) bool {
+				return bs.prefers(alternatives[i].Provider, alternatives[j].Provider)
+			})
+			bs.alternatives[room.Id] = alternatives
+		}
+	}
+}
+
+// refused reports whether a provider refused a booking, which means that it
+// wasn't made and another provider can be tried
+func refused(err error) bool {
+	for _, kind := range []error{
+		booking.ErrRoomUnavailable,
+		booking.ErrNoSuchRoom,
+		booking.ErrQuotaExceeded,
+		booking.ErrOutsideBookingWindow,
+	} {
+		if errors.Is(err, kind) {
+			return true
+		}
+	}
+	return false
+}
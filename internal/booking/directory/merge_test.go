@@ -0,0 +1,147 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+	fmtLog "sidus.io/boogrocha/internal/log/fmt"
+)
+
+// refusingService refuses every booking with err
+type refusingService struct {
+	booking.BookingService
+	err error
+}
+
+func (rs *refusingService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	return booking.Booking{}, rs.err
+}
+
+func TestBookingService_MergeRooms(t *testing.T) {
+	roomBA := booking.Room{Provider: providerB, Id: roomA, Seats: 6}
+	bs := NewBookingServiceWithOptions(map[string]booking.BookingService{
+		providerA: newStaticService(nil, []booking.Room{roomAA, roomAB}),
+		providerB: newStaticService(nil, []booking.Room{roomBA}),
+		providerC: newStaticService(nil, []booking.Room{roomCA}),
+	}, &fmtLog.Logger{}, Options{MergeRooms: true, Preferred: []string{providerC, providerA}})
+
+	got, err := bs.Available(context.Background(), time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("BookingService.Available() error = %v", err)
+	}
+
+	merged := roomCA
+	merged.Seats = 6
+	want := []booking.Room{merged, roomAB}
+	if !sameRooms(got, want) {
+		t.Errorf("BookingService.Available() = %v, want %v", got, want)
+	}
+
+	alternatives := bs.Alternatives(merged)
+	want = []booking.Room{merged, roomAA, roomBA}
+	if !reflect.DeepEqual(alternatives, want) {
+		t.Errorf("BookingService.Alternatives() = %v, want %v", alternatives, want)
+	}
+}
+
+func TestBookingService_MergeTimelines(t *testing.T) {
+	start := time.Date(2019, 10, 15, 8, 0, 0, 0, time.UTC)
+	step := 30 * time.Minute
+	bs := NewBookingServiceWithOptions(map[string]booking.BookingService{
+		providerA: &timelineService{timelines: []booking.Timeline{{Room: roomAA, Start: start, Step: step, Free: []bool{true, false}}}},
+		providerC: &timelineService{timelines: []booking.Timeline{{Room: roomCA, Start: start, Step: step, Free: []bool{false, false}}}},
+	}, &fmtLog.Logger{}, Options{MergeRooms: true, Preferred: []string{providerC}})
+
+	got, err := bs.Timeline(context.Background(), start, start.Add(time.Hour), step)
+	if err != nil {
+		t.Fatalf("BookingService.Timeline() error = %v", err)
+	}
+
+	want := []booking.Timeline{{Room: roomCA, Start: start, Step: step, Free: []bool{true, false}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BookingService.Timeline() = %v, want %v", got, want)
+	}
+}
+
+func TestBookingService_BookFailover(t *testing.T) {
+	tests := []struct {
+		name     string
+		services map[string]booking.BookingService
+		want     booking.Room
+		wantErr  []error
+	}{
+		{
+			name: "preferred provider books",
+			services: map[string]booking.BookingService{
+				providerA: newMockService([]booking.Room{roomAA}),
+				providerC: newMockService([]booking.Room{roomCA}),
+			},
+			want: roomCA,
+		},
+		{
+			name: "preferred provider refuses",
+			services: map[string]booking.BookingService{
+				providerA: newMockService([]booking.Room{roomAA}),
+				providerC: &refusingService{BookingService: newStaticService(nil, []booking.Room{roomCA}), err: booking.ErrQuotaExceeded},
+			},
+			want: roomAA,
+		},
+		{
+			name: "every provider refuses",
+			services: map[string]booking.BookingService{
+				providerA: &refusingService{BookingService: newStaticService(nil, []booking.Room{roomAA}), err: booking.ErrRoomUnavailable},
+				providerC: &refusingService{BookingService: newStaticService(nil, []booking.Room{roomCA}), err: booking.ErrRoomUnavailable},
+			},
+			wantErr: []error{ErrAllServicesFailed, booking.ErrRoomUnavailable},
+		},
+		{
+			name: "preferred provider fails",
+			services: map[string]booking.BookingService{
+				providerA: newMockService([]booking.Room{roomAA}),
+				providerC: &refusingService{BookingService: newStaticService(nil, []booking.Room{roomCA}), err: booking.ErrProviderUnreachable},
+			},
+			// The booking might have been made, so no other provider is tried
+			wantErr: []error{booking.ErrProviderUnreachable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := NewBookingServiceWithOptions(tt.services, &fmtLog.Logger{}, Options{MergeRooms: true, Preferred: []string{providerC}})
+
+			available, err := bs.Available(context.Background(), time.Time{}, time.Time{})
+			if err != nil || len(available) != 1 {
+				t.Fatalf("BookingService.Available() = %v, %v, want one room", available, err)
+			}
+
+			created, err := bs.Book(context.Background(), booking.Booking{Room: available[0]})
+			for _, want := range tt.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("BookingService.Book() error = %v, want %v", err, want)
+				}
+			}
+			if tt.wantErr == nil && (err != nil || created.Room != tt.want) {
+				t.Errorf("BookingService.Book() = %v, %v, want %v", created.Room, err, tt.want)
+			}
+		})
+	}
+}
+
+func sameRooms(a []booking.Room, b []booking.Room) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, room := range a {
+		found := false
+		for _, other := range b {
+			found = found || room == other
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
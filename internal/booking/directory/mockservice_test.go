@@ -0,0 +1,114 @@
+package directory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+// mockService books rooms without regard to time, a booked room stays booked
+// until it's unbooked
+type mockService struct {
+	Bookings map[booking.Room]*booking.Booking
+	Rooms    []booking.Room
+	nextId   int
+}
+
+func newMockService(rooms []booking.Room) *mockService {
+	return &mockService{Bookings: make(map[booking.Room]*booking.Booking), Rooms: rooms}
+}
+
+func (bs *mockService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	if bs.Bookings[b.Room] != nil {
+		return booking.Booking{}, booking.ErrRoomUnavailable
+	}
+
+	bs.nextId++
+	b.Id = fmt.Sprint(bs.nextId)
+	bs.Bookings[b.Room] = &b
+	return b, nil
+}
+
+func (bs *mockService) UnBook(ctx context.Context, b booking.Booking) error {
+	if bs.Bookings[b.Room] == nil {
+		return fmt.Errorf("room not booked")
+	}
+
+	delete(bs.Bookings, b.Room)
+	return nil
+}
+
+func (bs *mockService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	var bookings []booking.Booking
+	for _, v := range bs.Bookings {
+		bookings = append(bookings, *v)
+	}
+	return bookings, nil
+}
+
+func (bs *mockService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	var available []booking.Room
+	for _, room := range bs.Rooms {
+		if bs.Bookings[room] == nil {
+			available = append(available, room)
+		}
+	}
+	return available, nil
+}
+
+// errorService fails every call with err, or a generic error if err is nil
+type errorService struct {
+	err error
+}
+
+func (es *errorService) fail() error {
+	if es.err != nil {
+		return es.err
+	}
+	return fmt.Errorf("mock error")
+}
+
+func (es *errorService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	return booking.Booking{}, es.fail()
+}
+
+func (es *errorService) UnBook(ctx context.Context, b booking.Booking) error {
+	return es.fail()
+}
+
+func (es *errorService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	return nil, es.fail()
+}
+
+func (es *errorService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	return nil, es.fail()
+}
+
+// staticService books every room without remembering it, and always has
+// the same bookings and rooms available
+type staticService struct {
+	bookings []booking.Booking
+	rooms    []booking.Room
+}
+
+func newStaticService(bookings []booking.Booking, rooms []booking.Room) *staticService {
+	return &staticService{bookings: bookings, rooms: rooms}
+}
+
+func (*staticService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	return b, nil
+}
+
+func (*staticService) UnBook(ctx context.Context, b booking.Booking) error {
+	return nil
+}
+
+func (ss *staticService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	return ss.bookings, nil
+}
+
+func (ss *staticService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	return ss.rooms, nil
+}
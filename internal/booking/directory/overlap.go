@@ -0,0 +1,42 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+// CheckOverlap looks for b among the bookings of the user at bs. It returns
+// the booking of the same room at the same time if there is one, regardless
+// of its text, and fails with booking.ErrOverlappingBooking if b overlaps
+// with any other booking. Only the providers that answer are checked.
+func CheckOverlap(ctx context.Context, bs booking.BookingService, b booking.Booking) (booking.Booking, bool, error) {
+	bookings, err := bs.MyBookings(booking.WithoutText(ctx))
+	if err != nil && !errors.Is(err, ErrPartialResult) {
+		return booking.Booking{}, false, fmt.Errorf("couldn't check for overlapping bookings: %w", err)
+	}
+
+	for _, existing := range bookings {
+		if existing.Room.Id == b.Room.Id && existing.Start.Equal(b.Start) && existing.End.Equal(b.End) {
+			return existing, true, nil
+		}
+	}
+
+	if booking.OverlapAllowed(ctx) {
+		return booking.Booking{}, false, nil
+	}
+	// The overlapping booking is described in the time zone of b
+	loc := b.Start.Location()
+	for _, existing := range bookings {
+		if existing.Start.Before(b.End) && b.Start.Before(existing.End) {
+			return booking.Booking{}, false, booking.Wrap(booking.ErrOverlappingBooking, fmt.Errorf("%s %s-%s",
+				existing.Room.Id,
+				existing.Start.In(loc).Format("2006-01-02 15:04"),
+				existing.End.In(loc).Format("15:04"),
+			))
+		}
+	}
+	return booking.Booking{}, false, nil
+}
@@ -0,0 +1,121 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+	fmtLog "sidus.io/boogrocha/internal/log/fmt"
+)
+
+func TestBookingService_BookOverlap(t *testing.T) {
+	start := time.Date(2019, 10, 15, 10, 0, 0, 0, time.UTC)
+	existing := booking.Booking{Room: roomAA, Start: start, End: start.Add(2 * time.Hour), Id: "1"}
+
+	tests := []struct {
+		name    string
+		booking booking.Booking
+		allow   bool
+		want    booking.Booking
+		wantErr error
+	}{
+		{
+			name:    "overlap at another provider",
+			booking: booking.Booking{Room: roomCB, Start: start.Add(time.Hour), End: start.Add(3 * time.Hour)},
+			wantErr: booking.ErrOverlappingBooking,
+		},
+		{
+			name:    "overlap in the same room",
+			booking: booking.Booking{Room: roomAA, Start: start, End: start.Add(time.Hour)},
+			wantErr: booking.ErrOverlappingBooking,
+		},
+		{
+			name:    "overlap allowed",
+			booking: booking.Booking{Room: roomCB, Start: start.Add(time.Hour), End: start.Add(3 * time.Hour)},
+			allow:   true,
+			want:    booking.Booking{Room: roomCB, Start: start.Add(time.Hour), End: start.Add(3 * time.Hour)},
+		},
+		{
+			name:    "right after",
+			booking: booking.Booking{Room: roomCB, Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour)},
+			want:    booking.Booking{Room: roomCB, Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour)},
+		},
+		{
+			name:    "identical",
+			booking: booking.Booking{Room: roomAA, Start: start.In(time.FixedZone("CEST", 2*60*60)), End: start.Add(2 * time.Hour), Text: "Study"},
+			want:    existing,
+		},
+		{
+			name:    "identical while allowing overlaps",
+			booking: booking.Booking{Room: roomAA, Start: start, End: start.Add(2 * time.Hour)},
+			allow:   true,
+			want:    existing,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := NewBookingServiceWithOptions(map[string]booking.BookingService{
+				providerA: newStaticService([]booking.Booking{existing}, nil),
+				providerB: &errorService{},
+				providerC: newStaticService(nil, nil),
+			}, &fmtLog.Logger{}, Options{RefuseOverlaps: true})
+
+			ctx := context.Background()
+			if tt.allow {
+				ctx = booking.AllowOverlap(ctx)
+			}
+			got, err := bs.Book(ctx, tt.booking)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("BookingService.Book() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("BookingService.Book() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("BookingService.Book() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookingService_BookOverlapUnchecked(t *testing.T) {
+	bs := NewBookingServiceWithOptions(map[string]booking.BookingService{
+		providerA: &errorService{},
+		providerC: newStaticService(nil, nil),
+	}, &fmtLog.Logger{}, Options{RefuseOverlaps: true})
+
+	_, err := bs.Book(context.Background(), booking.Booking{Room: roomCA})
+	if err != nil {
+		t.Errorf("BookingService.Book() error = %v, want the bookings that were found to be checked", err)
+	}
+
+	delete(bs.providers, providerC)
+	bs.providers[providerB] = &errorService{}
+	_, err = bs.Book(context.Background(), booking.Booking{Room: roomAA})
+	if !errors.Is(err, ErrAllServicesFailed) {
+		t.Errorf("BookingService.Book() error = %v, want %v", err, ErrAllServicesFailed)
+	}
+}
+
+func TestCheckOverlap_TimeZone(t *testing.T) {
+	start := time.Date(2019, 10, 15, 10, 0, 0, 0, time.UTC)
+	existing := booking.Booking{Room: roomAA, Start: start, End: start.Add(2 * time.Hour), Id: "1"}
+	bs := newStaticService([]booking.Booking{existing}, nil)
+
+	cest := time.FixedZone("CEST", 2*60*60)
+	b := booking.Booking{Room: roomCB, Start: start.Add(time.Hour).In(cest), End: start.Add(3 * time.Hour).In(cest)}
+	_, _, err := CheckOverlap(context.Background(), bs, b)
+	if !errors.Is(err, booking.ErrOverlappingBooking) {
+		t.Fatalf("CheckOverlap() error = %v, want %v", err, booking.ErrOverlappingBooking)
+	}
+	if want := "2019-10-15 12:00-14:00"; !strings.Contains(err.Error(), want) {
+		t.Errorf("CheckOverlap() error = %q, want it to contain %q", err, want)
+	}
+}
@@ -0,0 +1,81 @@
+package directory
+
+import (
+	"context"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/parallel"
+)
+
+// Number of slots that are requested from a provider at the same time when
+// emulating a timeline
+const maxConcurrentSlots = 4
+
+func (bs *BookingService) Timeline(ctx context.Context, start time.Time, end time.Time, step time.Duration) ([]booking.Timeline, error) {
+	if len(bs.providers) == 0 {
+		return nil, ErrNoServices
+	}
+
+	timelines, errs := bs.timeline(ctx, start, end, step)
+	if len(errs) == len(bs.providers) {
+		return nil, &servicesFailedError{errs: errs}
+	}
+
+	return timelines, partialError(errs)
+}
+
+func (bs *BookingService) timeline(ctx context.Context, start time.Time, end time.Time, step time.Duration) ([]booking.Timeline, []*serviceError) {
+	results, errs := bs.fanOut(ctx, func(ctx context.Context, provider booking.BookingService) (interface{}, error) {
+		return Timeline(ctx, provider, start, end, step)
+	})
+
+	var timelines []booking.Timeline
+	for _, result := range results {
+		timelines = append(timelines, result.value.([]booking.Timeline)...)
+	}
+	return bs.mergeTimelines(timelines), errs
+}
+
+// Timeline returns the availability of the rooms of bs between start and
+// end in slots of length step. Services that don't implement
+// booking.TimelineService are asked for the available rooms of every slot.
+func Timeline(ctx context.Context, bs booking.BookingService, start time.Time, end time.Time, step time.Duration) ([]booking.Timeline, error) {
+	if ts, ok := bs.(booking.TimelineService); ok {
+		return ts.Timeline(ctx, start, end, step)
+	}
+	return emulateTimeline(ctx, bs, start, end, step)
+}
+
+func emulateTimeline(ctx context.Context, bs booking.BookingService, start time.Time, end time.Time, step time.Duration) ([]booking.Timeline, error) {
+	slots := booking.Slots(start, end, step)
+	available := make([][]booking.Room, len(slots))
+	err := parallel.Do(ctx, len(slots), maxConcurrentSlots, func(ctx context.Context, i int) error {
+		var err error
+		available[i], err = bs.Available(ctx, slots[i], slots[i].Add(step))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var timelines []booking.Timeline
+	index := make(map[booking.Room]int)
+	for i, rooms := range available {
+		for _, room := range rooms {
+			j, ok := index[room]
+			if !ok {
+				j = len(timelines)
+				index[room] = j
+				timelines = append(timelines, booking.Timeline{
+					Room:  room,
+					Start: start,
+					Step:  step,
+					Free:  make([]bool, len(slots)),
+				})
+			}
+			timelines[j].Free[i] = true
+		}
+	}
+	return timelines, nil
+}
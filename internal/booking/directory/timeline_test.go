@@ -0,0 +1,86 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+	fmtLog "sidus.io/boogrocha/internal/log/fmt"
+)
+
+// timelineService answers timelines natively but fails every other call
+type timelineService struct {
+	errorService
+	timelines []booking.Timeline
+}
+
+func (ts *timelineService) Timeline(ctx context.Context, start time.Time, end time.Time, step time.Duration) ([]booking.Timeline, error) {
+	return ts.timelines, nil
+}
+
+func TestBookingService_Timeline(t *testing.T) {
+	start := time.Date(2019, 10, 15, 8, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	step := 30 * time.Minute
+
+	native := booking.Timeline{
+		Room:  roomCA,
+		Start: start,
+		Step:  step,
+		Free:  []bool{false, true},
+	}
+
+	bs := NewBookingService(map[string]booking.BookingService{
+		providerA: newStaticService(nil, []booking.Room{roomAA, roomAB}),
+		providerB: &errorService{},
+		providerC: &timelineService{timelines: []booking.Timeline{native}},
+	}, &fmtLog.Logger{})
+
+	got, err := bs.Timeline(context.Background(), start, end, step)
+	if !errors.Is(err, ErrPartialResult) {
+		t.Errorf("BookingService.Timeline() error = %v, want %v", err, ErrPartialResult)
+		return
+	}
+
+	want := []booking.Timeline{
+		{Room: roomAA, Start: start, Step: step, Free: []bool{true, true}},
+		{Room: roomAB, Start: start, Step: step, Free: []bool{true, true}},
+		native,
+	}
+
+	sort.Slice(got, func(i, j int) bool {
+		if got[i].Room.Provider == got[j].Room.Provider {
+			return got[i].Room.Id < got[j].Room.Id
+		}
+		return got[i].Room.Provider < got[j].Room.Provider
+	})
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BookingService.Timeline() = %v, want %v", got, want)
+	}
+}
+
+func TestTimeline_Emulated(t *testing.T) {
+	start := time.Date(2019, 10, 15, 8, 0, 0, 0, time.UTC)
+
+	_, err := Timeline(context.Background(), &errorService{}, start, start.Add(time.Hour), 15*time.Minute)
+	if err == nil {
+		t.Errorf("Timeline() expected error from failing service")
+	}
+
+	got, err := Timeline(context.Background(), newStaticService(nil, []booking.Room{roomAA}), start, start.Add(time.Hour), 15*time.Minute)
+	if err != nil {
+		t.Errorf("Timeline() error = %v", err)
+		return
+	}
+	want := []booking.Timeline{
+		{Room: roomAA, Start: start, Step: 15 * time.Minute, Free: []bool{true, true, true, true}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Timeline() = %v, want %v", got, want)
+	}
+}
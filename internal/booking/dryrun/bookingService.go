@@ -0,0 +1,129 @@
+package dryrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/directory"
+)
+
+// BookingService reads from another booking service but only describes the
+// bookings it would make or remove instead of making or removing them
+type BookingService struct {
+	bs  booking.BookingService
+	out io.Writer
+}
+
+// NewBookingService returns a service which reads from bs and describes the
+// bookings it would make to out
+func NewBookingService(bs booking.BookingService, out io.Writer) *BookingService {
+	return &BookingService{bs: bs, out: out}
+}
+
+// Book describes the booking it would make. Like the booking services of
+// bgc it refuses bookings overlapping with the bookings of the user, and
+// returns the existing booking if the room is already booked at that time.
+// The described booking has no Id, since none was made.
+func (d *BookingService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	existing, found, err := directory.CheckOverlap(ctx, d.bs, b)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+	if found {
+		fmt.Fprintln(d.out, "Dry run, already booked:")
+		d.describe(existing)
+		return existing, nil
+	}
+
+	var form url.Values
+	if fs, ok := d.bs.(booking.FormService); ok {
+		var err error
+		form, err = fs.BookingForm(b)
+		if err != nil {
+			return booking.Booking{}, err
+		}
+	}
+
+	fmt.Fprintln(d.out, "Dry run, would book:")
+	d.describe(b)
+	if len(form) > 0 {
+		fmt.Fprintln(d.out, "  Form:")
+		keys := make([]string, 0, len(form))
+		for key := range form {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			for _, value := range form[key] {
+				fmt.Fprintf(d.out, "    %s=%s\n", key, value)
+			}
+		}
+	}
+
+	b.Id = ""
+	return b, nil
+}
+
+// UnBook describes the booking it would remove, and the request removing it
+// if the booking service can tell
+func (d *BookingService) UnBook(ctx context.Context, b booking.Booking) error {
+	var req *http.Request
+	if fs, ok := d.bs.(booking.FormService); ok {
+		var err error
+		req, err = fs.UnBookingRequest(ctx, b)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(d.out, "Dry run, would delete:")
+	d.describe(b)
+	fmt.Fprintf(d.out, "  Id:       %s\n", b.Id)
+	if req != nil {
+		fmt.Fprintf(d.out, "  Request:  %s %s\n", req.Method, req.URL)
+	}
+	return nil
+}
+
+func (d *BookingService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	return d.bs.MyBookings(ctx)
+}
+
+func (d *BookingService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	return d.bs.Available(ctx, start, end)
+}
+
+func (d *BookingService) Timeline(ctx context.Context, start time.Time, end time.Time, step time.Duration) ([]booking.Timeline, error) {
+	return directory.Timeline(ctx, d.bs, start, end, step)
+}
+
+func (d *BookingService) AvailableRange(ctx context.Context, start time.Time, end time.Time) ([]booking.DayAvailability, error) {
+	return directory.AvailableRange(ctx, d.bs, start, end)
+}
+
+func (d *BookingService) describe(b booking.Booking) {
+	// The times are shown in the time zone they were given in
+	start, end := b.Start, b.End
+	interval := fmt.Sprintf("%s-%s", start.Format("2006-01-02 15:04"), end.Format("15:04"))
+	if !sameDay(start, end) {
+		interval = fmt.Sprintf("%s-%s", start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"))
+	}
+
+	fmt.Fprintf(d.out, "  Provider: %s\n", b.Room.Provider)
+	fmt.Fprintf(d.out, "  Room:     %s\n", b.Room.Id)
+	fmt.Fprintf(d.out, "  Interval: %s\n", interval)
+	fmt.Fprintf(d.out, "  Text:     %q\n", strings.TrimSpace(b.Text))
+}
+
+func sameDay(a time.Time, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
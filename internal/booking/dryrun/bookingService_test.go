@@ -0,0 +1,107 @@
+package dryrun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/local"
+	"sidus.io/boogrocha/internal/booking/local/localtest"
+)
+
+// formService is a booking service telling the form it would send
+type formService struct {
+	local.BookingService
+}
+
+func (fs formService) BookingForm(b booking.Booking) (url.Values, error) {
+	return url.Values{"o": {"123", "203460.192"}, "fe2": {b.Text}}, nil
+}
+
+func (fs formService) UnBookingRequest(ctx context.Context, b booking.Booking) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, http.MethodDelete, "https://example.com/bookings?id="+b.Id, nil)
+}
+
+// newFormService returns a form service booking the rooms in a local file
+func newFormService(t *testing.T, now time.Time, rooms ...booking.Room) formService {
+	return formService{localtest.NewBookingService(t, now, rooms...)}
+}
+
+func TestBookingService_Book(t *testing.T) {
+	room := booking.Room{Provider: local.BaseProvider, Id: "EG-2515"}
+	start := time.Date(2020, 9, 28, 10, 0, 0, 0, time.UTC)
+	provider := newFormService(t, start.Add(-time.Hour), room)
+	var out bytes.Buffer
+	bs := NewBookingService(provider, &out)
+	ctx := context.Background()
+
+	b := booking.Booking{Room: room, Start: start, End: start.Add(2 * time.Hour), Text: "Study"}
+	created, err := bs.Book(ctx, b)
+	assert.NoError(t, err)
+	assert.Empty(t, created.Id, "Nothing was booked so there is no id")
+	assert.Equal(t, `Dry run, would book:
+  Provider: Local
+  Room:     EG-2515
+  Interval: 2020-09-28 10:00-12:00
+  Text:     "Study"
+  Form:
+    fe2=Study
+    o=123
+    o=203460.192
+`, out.String())
+
+	bookings, err := bs.MyBookings(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, bookings, "Nothing should be booked")
+	available, err := bs.Available(ctx, start, start.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []booking.Room{room}, available)
+
+	created, err = provider.Book(ctx, b)
+	assert.NoError(t, err)
+	out.Reset()
+	assert.NoError(t, bs.UnBook(ctx, created))
+	assert.Equal(t, `Dry run, would delete:
+  Provider: Local
+  Room:     EG-2515
+  Interval: 2020-09-28 10:00-12:00
+  Text:     "Study"
+  Id:       `+created.Id+`
+  Request:  DELETE https://example.com/bookings?id=`+created.Id+`
+`, out.String())
+	bookings, _ = bs.MyBookings(ctx)
+	assert.Len(t, bookings, 1, "Nothing should be deleted")
+}
+
+func TestBookingService_BookOverlap(t *testing.T) {
+	roomA := booking.Room{Provider: local.BaseProvider, Id: "A"}
+	roomB := booking.Room{Provider: local.BaseProvider, Id: "B"}
+	start := time.Date(2020, 9, 28, 10, 0, 0, 0, time.UTC)
+	provider := newFormService(t, start.Add(-time.Hour), roomA, roomB)
+	var out bytes.Buffer
+	bs := NewBookingService(provider, &out)
+	ctx := context.Background()
+
+	existing, err := provider.Book(ctx, booking.Booking{Room: roomA, Start: start, End: start.Add(2 * time.Hour)})
+	assert.NoError(t, err)
+
+	_, err = bs.Book(ctx, booking.Booking{Room: roomB, Start: start.Add(time.Hour), End: start.Add(3 * time.Hour)})
+	assert.True(t, errors.Is(err, booking.ErrOverlappingBooking), "The overlap should be refused like a real booking")
+	assert.Empty(t, out.String())
+
+	_, err = bs.Book(booking.AllowOverlap(ctx), booking.Booking{Room: roomB, Start: start.Add(time.Hour), End: start.Add(3 * time.Hour)})
+	assert.NoError(t, err)
+
+	out.Reset()
+	created, err := bs.Book(ctx, booking.Booking{Room: roomA, Start: start, End: start.Add(2 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Equal(t, existing.Id, created.Id, "Booking the same room again should return the existing booking")
+	assert.Contains(t, out.String(), "Dry run, already booked:")
+}
@@ -0,0 +1,49 @@
+package booking
+
+import "fmt"
+
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+const (
+	ErrRoomUnavailable      = Error("room is not available")
+	ErrNoSuchRoom           = Error("no such room")
+	ErrAuthenticationFailed = Error("authentication failed")
+	ErrQuotaExceeded        = Error("booking quota exceeded")
+	ErrOutsideBookingWindow = Error("outside of the booking window")
+	ErrProviderUnreachable  = Error("booking provider unreachable")
+	ErrParseFailure         = Error("couldn't parse response from booking provider")
+	ErrUnconfirmed          = Error("booking couldn't be confirmed")
+	ErrOverlappingBooking   = Error("overlaps with another booking")
+)
+
+// kindError annotates an error with one of the error kinds above while
+// keeping the original error available through errors.Unwrap.
+type kindError struct {
+	kind Error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return fmt.Sprintf("%s: %s", e.kind, e.err)
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *kindError) Unwrap() error {
+	return e.err
+}
+
+// Wrap returns an error matching kind with errors.Is which still unwraps
+// to err. Wrapping a nil error returns nil.
+func Wrap(kind Error, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{kind: kind, err: err}
+}
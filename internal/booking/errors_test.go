@@ -0,0 +1,23 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	err := Wrap(ErrProviderUnreachable, context.DeadlineExceeded)
+
+	assert.True(t, errors.Is(err, ErrProviderUnreachable))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.False(t, errors.Is(err, ErrAuthenticationFailed))
+
+	wrapped := fmt.Errorf("while booking: %w", err)
+	assert.True(t, errors.Is(wrapped, ErrProviderUnreachable))
+
+	assert.Nil(t, Wrap(ErrParseFailure, nil))
+}
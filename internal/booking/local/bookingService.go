@@ -0,0 +1,342 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+const BaseProvider = "Local"
+
+// A lock on the file older than this is assumed to have been left behind by
+// a process that died while holding it
+const staleLock = 10 * time.Second
+
+// How often a lock held by another process is tried again
+const lockRetry = 10 * time.Millisecond
+
+// DefaultRooms are the rooms of a new file when no rooms are given
+func DefaultRooms() []booking.Room {
+	return []booking.Room{
+		{Id: "Demo-1", Seats: 4, Campus: "Johanneberg"},
+		{Id: "Demo-2", Seats: 6, Campus: "Johanneberg"},
+		{Id: "Demo-3", Seats: 8, Campus: "Johanneberg"},
+		{Id: "Demo-4", Seats: 4, Campus: "Lindholmen"},
+		{Id: "Demo-5", Seats: 10, Campus: "Lindholmen"},
+	}
+}
+
+type room struct {
+	Id     string `json:"id"`
+	Seats  int    `json:"seats"`
+	Campus string `json:"campus"`
+}
+
+type reservation struct {
+	Id    string    `json:"id"`
+	Room  string    `json:"room"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Text  string    `json:"text"`
+}
+
+// state is the content of the file
+type state struct {
+	Rooms        []room        `json:"rooms"`
+	Reservations []reservation `json:"reservations"`
+	LastId       int           `json:"last_id"`
+}
+
+// BookingService books rooms listed in a local JSON file, without any
+// booking system involved. The bookings are kept in the same file, which is
+// locked while it's changed so that several processes can share it.
+type BookingService struct {
+	path string
+	now  func() time.Time
+}
+
+type Options struct {
+	// Rooms are written to the file if it doesn't exist yet, DefaultRooms
+	// are used if there are none
+	Rooms []booking.Room
+	// Now returns the current time, bookings that have ended aren't listed
+	// by MyBookings. time.Now is used if it isn't set.
+	Now func() time.Time
+}
+
+// NewBookingService returns a service backed by the file at path, which is
+// created if it doesn't exist
+func NewBookingService(path string, opts Options) (BookingService, error) {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	bs := BookingService{
+		path: path,
+		now:  opts.Now,
+	}
+
+	unlock, err := bs.lock(context.Background())
+	if err != nil {
+		return bs, err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		// Make sure the file can be read before it's used
+		_, err = bs.read()
+		return bs, err
+	}
+
+	rooms := opts.Rooms
+	if len(rooms) == 0 {
+		rooms = DefaultRooms()
+	}
+	var s state
+	for _, r := range rooms {
+		s.Rooms = append(s.Rooms, room{Id: r.Id, Seats: r.Seats, Campus: r.Campus})
+	}
+	return bs, bs.write(s)
+}
+
+func (bs BookingService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	if !b.Start.Before(b.End) {
+		return booking.Booking{}, fmt.Errorf("the booking has to end after it starts")
+	}
+
+	var created booking.Booking
+	err := bs.update(ctx, func(s *state) error {
+		if _, ok := s.room(b.Room.Id); !ok {
+			return booking.ErrNoSuchRoom
+		}
+		if s.overlaps(b.Room.Id, b.Start, b.End) {
+			return booking.ErrRoomUnavailable
+		}
+
+		s.LastId++
+		r := reservation{
+			Id:    strconv.Itoa(s.LastId),
+			Room:  b.Room.Id,
+			Start: b.Start,
+			End:   b.End,
+			Text:  b.Text,
+		}
+		s.Reservations = append(s.Reservations, r)
+		created = bs.booking(*s, r)
+		return nil
+	})
+	return created, err
+}
+
+func (bs BookingService) UnBook(ctx context.Context, b booking.Booking) error {
+	return bs.update(ctx, func(s *state) error {
+		for i, r := range s.Reservations {
+			if r.Id == b.Id {
+				s.Reservations = append(s.Reservations[:i], s.Reservations[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("no booking with id %s", b.Id)
+	})
+}
+
+func (bs BookingService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	s, err := bs.read()
+	if err != nil {
+		return nil, err
+	}
+
+	now := bs.now()
+	var bookings []booking.Booking
+	for _, r := range s.Reservations {
+		if r.End.After(now) {
+			bookings = append(bookings, bs.booking(s, r))
+		}
+	}
+	sort.Slice(bookings, func(i, j int) bool {
+		return bookings[i].Start.Before(bookings[j].Start)
+	})
+	return bookings, nil
+}
+
+func (bs BookingService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	s, err := bs.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var rooms []booking.Room
+	for _, r := range s.Rooms {
+		if !s.overlaps(r.Id, start, end) {
+			rooms = append(rooms, bs.bookingRoom(r))
+		}
+	}
+	return rooms, nil
+}
+
+func (bs BookingService) Provider() string {
+	return BaseProvider
+}
+
+func (bs BookingService) booking(s state, r reservation) booking.Booking {
+	rm, ok := s.room(r.Room)
+	if !ok {
+		// The room has been removed from the file since it was booked
+		rm = room{Id: r.Room}
+	}
+	return booking.Booking{
+		Room:  bs.bookingRoom(rm),
+		Start: r.Start,
+		End:   r.End,
+		Text:  r.Text,
+		Id:    r.Id,
+	}
+}
+
+func (bs BookingService) bookingRoom(r room) booking.Room {
+	return booking.Room{
+		Provider: bs.Provider(),
+		Id:       r.Id,
+		Seats:    r.Seats,
+		Campus:   r.Campus,
+	}
+}
+
+// update reads the file, applies f and writes the result unless f fails. The
+// file is locked throughout, so that no other process can book a room
+// between f checking that it's free and the booking being written.
+func (bs BookingService) update(ctx context.Context, f func(s *state) error) error {
+	unlock, err := bs.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s, err := bs.read()
+	if err != nil {
+		return err
+	}
+	err = f(&s)
+	if err != nil {
+		return err
+	}
+	return bs.write(s)
+}
+
+// lock waits until the file isn't locked by anyone else and locks it, the
+// returned function releases the lock. The lock is a file next to the file
+// which only one process can create.
+func (bs BookingService) lock(ctx context.Context) (func(), error) {
+	path := bs.path + ".lock"
+	err := os.MkdirAll(filepath.Dir(path), 0744)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLock {
+			removeStaleLock(path, info)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetry):
+		}
+	}
+}
+
+// removeStaleLock removes the lock at path if it's still the stale lock
+// described by stale. Other processes may find the same lock stale, and one
+// of them may already have replaced it with a lock of its own, so the lock
+// is first moved out of the way, which only one process can do, and put
+// back if it turns out to be another lock.
+func removeStaleLock(path string, stale os.FileInfo) {
+	moved := fmt.Sprintf("%s.%d.stale", path, os.Getpid())
+	if os.Rename(path, moved) != nil {
+		return
+	}
+	defer os.Remove(moved)
+
+	// A new file can get the inode of a removed one, but not its age
+	info, err := os.Stat(moved)
+	if err == nil && !(os.SameFile(info, stale) && info.ModTime().Equal(stale.ModTime())) {
+		// Fails if yet another process has taken the lock in the meantime,
+		// which it could only do since the lock was moved
+		_ = os.Link(moved, path)
+	}
+}
+
+func (bs BookingService) read() (state, error) {
+	var s state
+	data, err := ioutil.ReadFile(bs.path)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return s, fmt.Errorf("invalid bookings file %s: %w", bs.path, err)
+	}
+	return s, nil
+}
+
+// write replaces the file through a rename so that it's never left half written
+func (bs BookingService) write(s state) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(bs.path)
+	err = os.MkdirAll(dir, 0744)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, filepath.Base(bs.path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), bs.path)
+}
+
+func (s state) room(id string) (room, bool) {
+	for _, r := range s.Rooms {
+		if r.Id == id {
+			return r, true
+		}
+	}
+	return room{}, false
+}
+
+// overlaps reports whether the room is booked at any time between start and end
+func (s state) overlaps(roomId string, start time.Time, end time.Time) bool {
+	for _, r := range s.Reservations {
+		if r.Room == roomId && r.Start.Before(end) && start.Before(r.End) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+func newTestService(t *testing.T, now time.Time) (BookingService, string) {
+	path := filepath.Join(t.TempDir(), "local.json")
+	bs, err := NewBookingService(path, Options{
+		Rooms: []booking.Room{
+			{Id: "A", Seats: 4, Campus: "Johanneberg"},
+			{Id: "B", Seats: 8, Campus: "Lindholmen"},
+		},
+		Now: func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bs, path
+}
+
+func TestBookingService_Book(t *testing.T) {
+	start := time.Date(2020, 9, 28, 10, 0, 0, 0, time.UTC)
+	bs, _ := newTestService(t, start.Add(-time.Hour))
+	ctx := context.Background()
+
+	roomA := booking.Room{Provider: BaseProvider, Id: "A", Seats: 4, Campus: "Johanneberg"}
+	roomB := booking.Room{Provider: BaseProvider, Id: "B", Seats: 8, Campus: "Lindholmen"}
+
+	created, err := bs.Book(ctx, booking.Booking{Room: roomA, Start: start, End: start.Add(2 * time.Hour), Text: "Study"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", created.Id)
+	assert.Equal(t, roomA, created.Room)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		room  booking.Room
+		want  error
+	}{
+		{name: "overlapping start", start: start.Add(-time.Hour), end: start.Add(time.Hour), room: roomA, want: booking.ErrRoomUnavailable},
+		{name: "inside", start: start.Add(30 * time.Minute), end: start.Add(time.Hour), room: roomA, want: booking.ErrRoomUnavailable},
+		{name: "other room", start: start, end: start.Add(time.Hour), room: roomB},
+		{name: "right after", start: start.Add(2 * time.Hour), end: start.Add(3 * time.Hour), room: roomA},
+		{name: "right before", start: start.Add(-time.Hour), end: start, room: roomA},
+		{name: "unknown room", start: start, end: start.Add(time.Hour), room: booking.Room{Id: "C"}, want: booking.ErrNoSuchRoom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := bs.Book(ctx, booking.Booking{Room: tt.room, Start: tt.start, End: tt.end})
+			if tt.want == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tt.want), "got %v", err)
+			}
+		})
+	}
+
+	available, err := bs.Available(ctx, start, start.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, available)
+	available, err = bs.Available(ctx, start.Add(time.Hour), start.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []booking.Room{roomB}, available)
+}
+
+func TestBookingService_MyBookings(t *testing.T) {
+	now := time.Date(2020, 9, 28, 12, 0, 0, 0, time.UTC)
+	bs, path := newTestService(t, now)
+	ctx := context.Background()
+
+	room := booking.Room{Provider: BaseProvider, Id: "A", Seats: 4, Campus: "Johanneberg"}
+	later, err := bs.Book(ctx, booking.Booking{Room: room, Start: now.Add(24 * time.Hour), End: now.Add(25 * time.Hour)})
+	assert.NoError(t, err)
+	_, err = bs.Book(ctx, booking.Booking{Room: room, Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)})
+	assert.NoError(t, err)
+	soon, err := bs.Book(ctx, booking.Booking{Room: room, Start: now.Add(-time.Hour), End: now.Add(time.Hour)})
+	assert.NoError(t, err)
+
+	bookings, err := bs.MyBookings(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []booking.Booking{soon, later}, bookings, "Ended bookings shouldn't be listed")
+
+	// The bookings should survive opening the file again
+	bs, err = NewBookingService(path, Options{Now: func() time.Time { return now }})
+	assert.NoError(t, err)
+	assert.NoError(t, bs.UnBook(ctx, soon))
+	assert.Error(t, bs.UnBook(ctx, soon))
+
+	bookings, err = bs.MyBookings(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []booking.Booking{later}, bookings)
+
+	next, err := bs.Book(ctx, booking.Booking{Room: room, Start: now, End: now.Add(time.Hour)})
+	assert.NoError(t, err)
+	assert.Equal(t, "4", next.Id, "Ids shouldn't be reused")
+}
+
+func TestBookingService_Lock(t *testing.T) {
+	start := time.Date(2020, 9, 28, 10, 0, 0, 0, time.UTC)
+	_, path := newTestService(t, start.Add(-time.Hour))
+	ctx := context.Background()
+	room := booking.Room{Provider: BaseProvider, Id: "A"}
+
+	// Services of their own stand in for separate processes sharing the file
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			bs, err := NewBookingService(path, Options{})
+			if err == nil {
+				_, err = bs.Book(ctx, booking.Booking{Room: room, Start: start, End: start.Add(time.Hour)})
+			}
+			errs <- err
+		}()
+	}
+	booked := 0
+	for i := 0; i < cap(errs); i++ {
+		err := <-errs
+		if err == nil {
+			booked++
+		} else {
+			assert.True(t, errors.Is(err, booking.ErrRoomUnavailable), "unexpected error %v", err)
+		}
+	}
+	assert.Equal(t, 1, booked, "The room should only be booked once")
+}
+
+func TestBookingService_HeldLock(t *testing.T) {
+	start := time.Date(2020, 9, 28, 10, 0, 0, 0, time.UTC)
+	bs, path := newTestService(t, start.Add(-time.Hour))
+	room := booking.Room{Provider: BaseProvider, Id: "A"}
+
+	assert.NoError(t, ioutil.WriteFile(path+".lock", nil, 0644))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := bs.Book(ctx, booking.Booking{Room: room, Start: start, End: start.Add(time.Hour)})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "Booking should wait for the lock of another process")
+
+	// A lock left behind by a process that died is taken over
+	old := time.Now().Add(-2 * staleLock)
+	assert.NoError(t, os.Chtimes(path+".lock", old, old))
+	_, err = bs.Book(context.Background(), booking.Booking{Room: room, Start: start, End: start.Add(time.Hour)})
+	assert.NoError(t, err)
+	_, err = os.Stat(path + ".lock")
+	assert.True(t, os.IsNotExist(err), "The lock should be released")
+}
+
+func TestRemoveStaleLock(t *testing.T) {
+	_, path := newTestService(t, time.Now())
+	lock := path + ".lock"
+
+	assert.NoError(t, ioutil.WriteFile(lock, nil, 0644))
+	old := time.Now().Add(-2 * staleLock)
+	assert.NoError(t, os.Chtimes(lock, old, old))
+	stale, err := os.Stat(lock)
+	assert.NoError(t, err)
+
+	// Another process finds the same lock stale and replaces it first
+	assert.NoError(t, os.Remove(lock))
+	assert.NoError(t, ioutil.WriteFile(lock, nil, 0644))
+	removeStaleLock(lock, stale)
+	_, err = os.Stat(lock)
+	assert.NoError(t, err, "The lock of the other process should be kept")
+
+	current, err := os.Stat(lock)
+	assert.NoError(t, err)
+	removeStaleLock(lock, current)
+	_, err = os.Stat(lock)
+	assert.True(t, os.IsNotExist(err), "The stale lock should be removed")
+	files, _ := filepath.Glob(lock + "*")
+	assert.Empty(t, files, "Nothing should be left behind")
+}
@@ -0,0 +1,26 @@
+// Package localtest provides local booking services for the tests of the
+// services built on top of other booking services
+package localtest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/local"
+)
+
+// NewBookingService returns a local booking service with the rooms, which
+// keeps its bookings in a directory removed when the test ends. now is the
+// current time of the service.
+func NewBookingService(t testing.TB, now time.Time, rooms ...booking.Room) local.BookingService {
+	bs, err := local.NewBookingService(filepath.Join(t.TempDir(), "local.json"), local.Options{
+		Rooms: rooms,
+		Now:   func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bs
+}
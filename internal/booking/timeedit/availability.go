@@ -0,0 +1,32 @@
+package timeedit
+
+import (
+	"context"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+// AvailableRange returns the rooms available between the time of day of start
+// and of end on every day from start to end, with a single query for all of
+// the days. TimeEdit only lists the rooms that are free on every date of a
+// query, so every day gets the same rooms.
+func (bs BookingService) AvailableRange(ctx context.Context, start time.Time, end time.Time) ([]booking.DayAvailability, error) {
+	days := booking.Days(start, end)
+	if len(days) == 0 {
+		return days, nil
+	}
+
+	first, last := days[0], days[len(days)-1]
+	rs, err := bs.getRooms(ctx, availabilityQuery(first.Start.In(bs.location), last.End.In(bs.location)))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range days {
+		for _, r := range rs {
+			days[i].Rooms = append(days[i].Rooms, bs.bookingRoom(r))
+		}
+	}
+	return days, nil
+}
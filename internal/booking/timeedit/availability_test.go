@@ -0,0 +1,59 @@
+package timeedit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+func TestAvailabilityQuery(t *testing.T) {
+	start := time.Date(2020, 9, 28, 13, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "dates=20200928-20200928&starttime=13:00&endtime=15:00",
+		availabilityQuery(start, start.Add(2*time.Hour)))
+	assert.Equal(t, "dates=20200928-20201002&starttime=13:00&endtime=15:00",
+		availabilityQuery(start, time.Date(2020, 10, 2, 15, 0, 0, 0, time.UTC)),
+		"The date of the end should be included")
+	assert.Equal(t, "dates=20200928-20200928&starttime=22:00&endtime=24:00",
+		availabilityQuery(start.Add(9*time.Hour), time.Date(2020, 9, 29, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestSplitAtMidnight(t *testing.T) {
+	start := time.Date(2020, 9, 28, 22, 0, 0, 0, time.UTC)
+	end := time.Date(2020, 9, 29, 2, 0, 0, 0, time.UTC)
+	midnight := time.Date(2020, 9, 29, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, [][2]time.Time{{start, midnight}, {midnight, end}}, splitAtMidnight(start, end))
+	assert.Equal(t, [][2]time.Time{{start, midnight}}, splitAtMidnight(start, midnight))
+	assert.Empty(t, splitAtMidnight(end, start))
+}
+
+func TestBookingService_AvailableRange(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("dates"))
+		_, _ = w.Write([]byte(`{"hasMore":false,"objects":[{"idAndType":"123.186","fields":{"Lokalsignatur":"EG-2515"}}]}`))
+	}))
+	defer server.Close()
+
+	instance := Instance{Name: "test", BaseURL: server.URL}
+	s, err := newSession(instance, "", nil, nil, http.DefaultTransport)
+	assert.NoError(t, err)
+	bs := BookingService{session: s, instance: instance, location: time.UTC}
+
+	start := time.Date(2020, 9, 28, 13, 0, 0, 0, time.UTC)
+	days, err := bs.AvailableRange(context.Background(), start, time.Date(2020, 10, 2, 15, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"20200928-20201002"}, queries, "Every day should be asked for at once")
+	assert.Len(t, days, 5)
+	for _, day := range days {
+		assert.Equal(t, []booking.Room{{Provider: bs.Provider(), Id: "EG-2515"}}, day.Rooms)
+	}
+	assert.Equal(t, start.AddDate(0, 0, 4), days[4].Start)
+}
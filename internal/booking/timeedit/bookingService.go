@@ -1,56 +1,54 @@
 package timeedit
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
 	"strings"
 	"time"
 
-	"golang.org/x/net/publicsuffix"
-
 	"github.com/PuerkitoBio/goquery"
 
 	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/catalog"
 )
 
-const samlURLFormat = "https://cloud.timeedit.net/%s/web/timeedit/sso/%s?back=https://cloud.timeedit.net/%s/web/b1/"
-const bookURLFormat = "https://cloud.timeedit.net/%s/web/b1/ri1Q5008.html"
-const objectsURLFormat = "https://cloud.timeedit.net/%s/web/b1/objects.json?part=t&types=186&step=1"
-const bookingsURLFormat = "https://cloud.timeedit.net/%s/web/b1/my.html"
-const roomInfoURL = "https://boogrocha.sidus.io/rooms.json"
-
-const studentUnionRoomFilter = "&sid=1010"
-const otherPurpose = "203460.192"
 const BaseProvider = "TimeEdit"
 
+// RoomCatalog provides the seats and campus of the rooms, which TimeEdit
+// doesn't know about
+type RoomCatalog interface {
+	Get(ctx context.Context) (catalog.Catalog, error)
+}
+
 type BookingService struct {
-	client  *http.Client
-	rooms   rooms
-	version version
+	session  *session
+	catalog  RoomCatalog
+	rooms    rooms
+	instance Instance
+	// location is the time zone of the instance, every time sent to or read
+	// from TimeEdit is in it
+	location *time.Location
 }
 
-func (bs BookingService) Book(booking booking.Booking) error {
-	bookingURL := fmt.Sprintf(bookURLFormat, bs.version.String())
+func (bs BookingService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	err := bs.book(ctx, b)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+	return bs.confirm(ctx, b)
+}
 
-	formData := url.Values{}
-	roomId, err := bs.rooms.idFromName(booking.Room.Id)
+func (bs BookingService) book(ctx context.Context, booking booking.Booking) error {
+	formData, err := bs.BookingForm(booking)
 	if err != nil {
 		return err
 	}
-	formData.Add("o", roomId)       // Denotes the room
-	formData.Add("o", otherPurpose) // Denotes the purpose always "other"
-	formData.Add("dates", booking.Start.Format("20060102"))
-	formData.Add("starttime", booking.Start.Format("15:04"))
-	formData.Add("endtime", booking.End.Format("15:04"))
-	formData.Add("fe2", booking.Text)
-	formData.Add("fe8", "Booked with BookingDemo") // Todo
-	formData.Add("url", bookingURL)
-	resp, err := bs.client.PostForm(bookingURL, formData)
+	resp, err := bs.session.postForm(ctx, bs.instance.bookURL(), formData)
 	if err != nil {
 		return err
 	}
@@ -59,138 +57,235 @@ func (bs BookingService) Book(booking booking.Booking) error {
 	if resp.StatusCode != 200 {
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return fmt.Errorf("failed to book room (%d)", resp.StatusCode)
+			return statusError(resp.StatusCode, "book room")
 		}
-		return fmt.Errorf(string(body))
+		return refusalError(resp.StatusCode, string(body))
 	}
 	return nil
 }
 
-func (bs BookingService) UnBook(booking booking.Booking) error {
-	bookingsURL := fmt.Sprintf(bookingsURLFormat, bs.version.String())
+// BookingForm returns the form which is posted to book a room
+func (bs BookingService) BookingForm(booking booking.Booking) (url.Values, error) {
+	formData := url.Values{}
+	roomId, err := bs.rooms.idFromName(booking.Room.Id)
+	if err != nil {
+		return nil, err
+	}
+	formData.Add("o", roomId)              // Denotes the room
+	formData.Add("o", bs.instance.Purpose) // Denotes the purpose, usually "other"
+	start, end := booking.Start.In(bs.location), booking.End.In(bs.location)
+	formData.Add("dates", start.Format("20060102"))
+	formData.Add("starttime", start.Format("15:04"))
+	formData.Add("endtime", end.Format("15:04"))
+	formData.Add("fe2", booking.Text)
+	formData.Add("fe8", "Booked with BookingDemo") // Todo
+	formData.Add("url", bs.instance.bookURL())
+	return formData, nil
+}
+
+// confirm looks for the booking among the bookings of the user since
+// TimeEdit accepting the form doesn't prove that the booking was made
+func (bs BookingService) confirm(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	bookings, err := bs.MyBookings(booking.WithoutText(ctx))
+	if err != nil {
+		return booking.Booking{}, booking.Wrap(booking.ErrUnconfirmed, err)
+	}
+	for _, created := range bookings {
+		if created.Room.Id == b.Room.Id && created.Start.Equal(b.Start) && created.End.Equal(b.End) {
+			created.Room = b.Room
+			created.Text = b.Text
+			return created, nil
+		}
+	}
+	return booking.Booking{}, booking.Wrap(booking.ErrUnconfirmed, fmt.Errorf("booking of %s not found among your bookings", b.Room.Id))
+}
+
+// UnBookingRequest returns the request which is sent to remove a booking
+func (bs BookingService) UnBookingRequest(ctx context.Context, booking booking.Booking) (*http.Request, error) {
+	bookingsURL := bs.instance.bookingsURL()
+	return http.NewRequestWithContext(ctx, "DELETE", fmt.Sprintf("%s?id=%s", bookingsURL, booking.Id), nil)
+}
+
+func (bs BookingService) UnBook(ctx context.Context, b booking.Booking) error {
+	err := bs.unBook(ctx, b)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	// A retried request fails when an earlier attempt removed the booking
+	// but its response was lost, which only the bookings of the user tell
+	bookings, listErr := bs.MyBookings(booking.WithoutText(ctx))
+	if listErr != nil {
+		return err
+	}
+	for _, existing := range bookings {
+		if existing.Id == b.Id {
+			return err
+		}
+	}
+	return nil
+}
 
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s?id=%s", bookingsURL, booking.Id), nil)
+func (bs BookingService) unBook(ctx context.Context, booking booking.Booking) error {
+	req, err := bs.UnBookingRequest(ctx, booking)
 	if err != nil {
 		return err
 	}
 
 	// Fetch Request
-	resp, err := bs.client.Do(req)
+	resp, err := bs.session.do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("failed to unbook")
+		return statusError(resp.StatusCode, "unbook")
 	}
 
 	return nil
 }
 
-func (bs BookingService) MyBookings() ([]booking.Booking, error) {
-	bookingsURL := fmt.Sprintf(bookingsURLFormat, bs.version.String())
-	resp, err := bs.client.Get(bookingsURL)
+func (bs BookingService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	bookingsURL := bs.instance.bookingsURL()
+	resp, err := bs.session.get(ctx, bookingsURL)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, statusError(resp.StatusCode, "get bookings")
+	}
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, parseError(err)
+	}
+
+	bookings, hasTexts, err := bs.parseBookings(doc)
 	if err != nil {
 		return nil, err
 	}
 
-	// Find the table with the bookings in
-	selections := doc.Find("body #texttable table tr")
+	// Older layouts of the list don't show the texts, they then have to be
+	// fetched one booking at a time
+	if !hasTexts && !booking.SkipText(ctx) {
+		err = bs.fillTexts(ctx, bookings)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bookings, nil
+}
 
-	// The important information starts on the third row
-	trs := make([]*goquery.Selection, selections.Length()-2)
-	selections.Each(func(i int, selection *goquery.Selection) {
-		if i >= 2 {
-			trs[i-2] = selection
+func (bs BookingService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	// TimeEdit applies the start and end time to every date of the query, so
+	// an interval spanning midnight is asked for one day at a time
+	var rooms rooms
+	for i, part := range splitAtMidnight(start.In(bs.location), end.In(bs.location)) {
+		rs, err := bs.getRooms(ctx, availabilityQuery(part[0], part[1]))
+		if err != nil {
+			return nil, err
 		}
-	})
-	bookings := make([]booking.Booking, 0, 4)
-	selectedDate := ""
-	for _, tr := range trs {
-		// Check if the row is a date row
-		headline := tr.Find(".headline.t")
-		if headline.Length() > 0 {
-			// If it is a date row we extract the date and move to the next row
-			text := headline.Text()
-			selectedDate = strings.Split(text, " ")[1]
+		if i == 0 {
+			rooms = rs
 		} else {
-			// If it isn't and we have no selected date somethings wrong
-			if selectedDate == "" {
-				return nil, fmt.Errorf("parsing failure")
-			}
-			id, found := tr.Attr("data-id")
-			if !found {
-				return nil, fmt.Errorf("parsing failure")
-			}
-
-			roomInfo := strings.Split(tr.Find(".column0").Text(), ", ")[0]
-
-			startTime, endTime, err := getBookingPeriod(tr, selectedDate)
-			if err != nil {
-				return nil, err
-			}
-
-			text, err := bs.getText(id)
-			if err != nil {
-				return nil, err
-			}
-
-			bookings = append(bookings, booking.Booking{
-				Text:  text,
-				Start: startTime,
-				End:   endTime,
-				Room: booking.Room{
-					Provider: bs.Provider(),
-					Id:       roomInfo,
-				},
-				Id: id,
-			})
+			rooms = rooms.intersect(rs)
 		}
 	}
-	return bookings, nil
+	var result []booking.Room
+
+	for _, room := range rooms {
+		result = append(result, bs.bookingRoom(room))
+	}
+	return result, nil
 }
 
-func (bs BookingService) Available(start time.Time, end time.Time) ([]booking.Room, error) {
-	date := start.Format("20060102")
-	dates := fmt.Sprintf("%s-%s", date, date)
+func (bs BookingService) bookingRoom(r room) booking.Room {
+	return booking.Room{
+		Provider: bs.Provider(),
+		Id:       r.Name,
+		Seats:    r.Seats,
+		Campus:   r.Campus,
+	}
+}
 
+// availabilityQuery asks for the rooms free between the time of day of
+// start and of end on every date from start to end. The times have to be in
+// the time zone of the instance.
+func availabilityQuery(start time.Time, end time.Time) string {
 	startTime := start.Format("15:04")
 	endTime := end.Format("15:04")
-
-	rooms, err := bs.getRooms(fmt.Sprintf("dates=%s&starttime=%s&endtime=%s", dates, startTime, endTime))
-	if err != nil {
-		return nil, err
+	lastDate := end
+	if endTime == "00:00" && end.After(start) {
+		// Ending at midnight ends the day before
+		endTime = "24:00"
+		lastDate = end.AddDate(0, 0, -1)
 	}
-	var result []booking.Room
 
-	for _, room := range rooms {
-		result = append(result, booking.Room{
-			Provider: bs.Provider(),
-			Id:       room.Name,
-			Seats:    room.Seats,
-			Campus:   room.Campus,
-		})
+	dates := fmt.Sprintf("%s-%s", start.Format("20060102"), lastDate.Format("20060102"))
+
+	return fmt.Sprintf("dates=%s&starttime=%s&endtime=%s", dates, startTime, endTime)
+}
+
+// splitAtMidnight divides an interval into the part of every day it covers
+func splitAtMidnight(start time.Time, end time.Time) [][2]time.Time {
+	var parts [][2]time.Time
+	for start.Before(end) {
+		midnight := time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, start.Location())
+		if !midnight.Before(end) {
+			midnight = end
+		}
+		parts = append(parts, [2]time.Time{start, midnight})
+		start = midnight
 	}
-	return result, nil
+	return parts
 }
 
 func (bs BookingService) Provider() string {
-	return BaseProvider + bs.version.String()
+	return BaseProvider + bs.instance.Name
+}
+
+type Options struct {
+	Cid string
+	// Password is only called when a new login is required
+	Password func() string
+	// Sessions is used to reuse logged in sessions between runs if set
+	Sessions SessionStore
+	// Catalog adds the seats and campus to the rooms if set
+	Catalog RoomCatalog
+	// Transport sends the requests to TimeEdit, http.DefaultTransport is
+	// used if it isn't set
+	Transport http.RoundTripper
 }
 
-func NewBookingService(cid string, pass string, timeEditVersion version) (BookingService, error) {
-	bs, err := login(cid, pass, timeEditVersion)
+func NewBookingService(ctx context.Context, instance Instance, opts Options) (BookingService, error) {
+	instance, err := instance.withDefaults()
+	if err != nil {
+		return BookingService{}, err
+	}
+	location, err := time.LoadLocation(instance.TimeZone)
+	if err != nil {
+		return BookingService{}, fmt.Errorf("couldn't load the time zone of %s: %w", instance, err)
+	}
+
+	s, err := newSession(instance, opts.Cid, opts.Password, opts.Sessions, opts.Transport)
 	if err != nil {
 		return BookingService{}, err
 	}
 
-	rs, err := bs.getRooms("")
+	err = s.ensure(ctx)
+	if err != nil {
+		return BookingService{}, err
+	}
+
+	bs := BookingService{
+		session:  s,
+		catalog:  opts.Catalog,
+		instance: instance,
+		location: location,
+	}
+
+	rs, err := bs.getRooms(ctx, "")
 	if err != nil {
 		return BookingService{}, err
 	}
@@ -199,17 +294,20 @@ func NewBookingService(cid string, pass string, timeEditVersion version) (Bookin
 	return bs, nil
 }
 
-func (bs BookingService) getText(id string) (string, error) {
-	bookingsURL := fmt.Sprintf(bookingsURLFormat, bs.version.String())
-	resp, err := bs.client.Get(fmt.Sprintf("%s?step=3&id=%s", bookingsURL, id))
+func (bs BookingService) getText(ctx context.Context, id string) (string, error) {
+	bookingsURL := bs.instance.bookingsURL()
+	resp, err := bs.session.get(ctx, fmt.Sprintf("%s?step=3&id=%s", bookingsURL, id))
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", statusError(resp.StatusCode, "get booking")
+	}
 
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
-		return "", err
+		return "", parseError(err)
 	}
 	text := ""
 	rows := doc.Find(".detailedResObjects tr")
@@ -224,29 +322,14 @@ func (bs BookingService) getText(id string) (string, error) {
 // This function gets more information about the rooms, like on which
 // campus it is or how many seats it has. This information doesn't
 // exists on TimeEdit at the time of writing this so therefore it has been
-// collected from chalmers maps. This process requires multiple api calls per room
-// has therefore been summarised into a json and is hosted by us.
-func (bs BookingService) getRoomInfo(rs rooms) (rooms, error) {
-	var roomInfos map[string]struct {
-		Seats  int    `json:"seats"`
-		Campus string `json:"campus"`
+// collected from chalmers maps into the room catalog.
+func (bs BookingService) getRoomInfo(ctx context.Context, rs rooms) (rooms, error) {
+	if bs.catalog == nil {
+		return rs, nil
 	}
 
-	resp, err := bs.client.Get(roomInfoURL)
+	roomInfos, err := bs.catalog.Get(ctx)
 	if err != nil {
-		fmt.Println("couldn't get room info json")
-		return rs, err
-	}
-	jsonBytes, err := ioutil.ReadAll(resp.Body)
-	_ = resp.Body.Close()
-	if err != nil {
-		fmt.Println("couldn't read response body")
-		return rs, err
-	}
-
-	err = json.Unmarshal(jsonBytes, &roomInfos)
-	if err != nil {
-		fmt.Println("couldn't unmarshal room info json")
 		return rs, err
 	}
 
@@ -258,36 +341,23 @@ func (bs BookingService) getRoomInfo(rs rooms) (rooms, error) {
 	return rs, nil
 }
 
-func (bs BookingService) getRooms(extra string) (rooms, error) {
-
-	objectsURL := fmt.Sprintf(objectsURLFormat, bs.version)
-
-	if extra != "" {
-		objectsURL = fmt.Sprintf("%s&%s", objectsURL, extra)
-	}
-
-	if bs.version == VersionChalmers {
-		extra += studentUnionRoomFilter
-	}
-
-	objectsURL += extra
-
-	rs, err := bs.fetchRooms(objectsURL)
+func (bs BookingService) getRooms(ctx context.Context, extra string) (rooms, error) {
+	rs, err := bs.fetchRooms(ctx, bs.instance.objectsURL(extra))
 	if err != nil {
 		return nil, err
 	}
 
-	// Since student union room shouldn't be booked on chalmers_covid we
-	// remove them from this list.
-	if bs.version == VersionChalmersCovid {
-		studentUnionRooms, err := bs.fetchRooms(objectsURL + studentUnionRoomFilter)
+	// Some instances list rooms which shouldn't be booked there, like the
+	// student union rooms on chalmers_covid, so we remove them from this list.
+	for _, exclusion := range bs.instance.Exclusions {
+		excluded, err := bs.fetchRooms(ctx, bs.instance.objectsURL(extra, exclusion))
 		if err != nil {
 			return nil, err
 		}
-		rs = rs.removeMany(studentUnionRooms)
+		rs = rs.removeMany(excluded)
 	}
 
-	rs, err = bs.getRoomInfo(rs)
+	rs, err = bs.getRoomInfo(ctx, rs)
 	if err != nil {
 		fmt.Println("couldn't get room info")
 		return nil, err
@@ -296,7 +366,7 @@ func (bs BookingService) getRooms(extra string) (rooms, error) {
 	return rs, nil
 }
 
-func (bs BookingService) fetchRooms(objectsURL string) (rooms, error) {
+func (bs BookingService) fetchRooms(ctx context.Context, objectsURL string) (rooms, error) {
 	var jsonResponse struct {
 		HasMore bool `json:"hasMore"`
 		Rooms   []struct {
@@ -314,7 +384,7 @@ func (bs BookingService) fetchRooms(objectsURL string) (rooms, error) {
 
 	for {
 		requestURL := fmt.Sprintf("%s&max=%d&start=%d", objectsURL, max, start)
-		resp, err := bs.client.Get(requestURL)
+		resp, err := bs.session.get(ctx, requestURL)
 		if err != nil {
 			return nil, err
 		}
@@ -335,7 +405,7 @@ func (bs BookingService) fetchRooms(objectsURL string) (rooms, error) {
 
 		err = json.Unmarshal(jsonBytes, &jsonResponse)
 		if err != nil {
-			return nil, err
+			return nil, parseError(err)
 		}
 
 		for _, r := range jsonResponse.Rooms {
@@ -354,96 +424,6 @@ func (bs BookingService) fetchRooms(objectsURL string) (rooms, error) {
 	return rs, nil
 }
 
-func login(cid string, pass string, timeEditVersion version) (BookingService, error) {
-	// Setup http client with a cookie jar
-	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
-	if err != nil {
-		return BookingService{}, err
-	}
-	client := &http.Client{
-		Jar: jar,
-	}
-	var saml string
-	if timeEditVersion == VersionChalmersCovid {
-		saml = "saml2_covid"
-	} else {
-		saml = "saml2"
-	}
-
-	samlURL := fmt.Sprintf(samlURLFormat, timeEditVersion, saml, timeEditVersion)
-
-	// Initiate SAML auth flow
-	resp, err := client.Get(samlURL)
-	if err != nil {
-		return BookingService{}, err
-	}
-
-	// Extract login form from request to cover XSS prevention values
-	form, err := getForm(resp, "#loginForm")
-	_ = resp.Body.Close()
-	if err != nil {
-		return BookingService{}, err
-	}
-
-	// Populate form with username and password
-	form.Values.Add("UserName", toUsername(cid))
-	form.Values.Add("Password", pass)
-
-	// Submit login form
-	resp, err = form.Post(client)
-	if err != nil {
-		return BookingService{}, err
-	}
-
-	// The IDP responds with a form that redirects to the original site,
-	// this form is usually auto submitted by a script snippet but we have to submit it ourselves
-	form, err = getForm(resp, "form")
-	_ = resp.Body.Close()
-	if err != nil {
-		return BookingService{}, err
-	}
-
-	// Check if login was successful
-	success := false
-	for key := range form.Values {
-		if key == "SAMLResponse" {
-			success = true
-			break
-		}
-	}
-	if !success {
-		return BookingService{}, fmt.Errorf("failed to login")
-	}
-
-	// Submit the redirect form
-	resp, err = form.Post(client)
-	if err != nil {
-		return BookingService{}, err
-	}
-	_ = resp.Body.Close()
-
-	// Check that we got the auth cookie
-	u, err := url.Parse(form.Action)
-	if err != nil {
-		return BookingService{}, err
-	}
-	success = false
-	for _, cookie := range jar.Cookies(u) {
-		if cookie.Name == fmt.Sprintf("TE%sweb", timeEditVersion) {
-			success = true
-			break
-		}
-	}
-	if !success {
-		return BookingService{}, fmt.Errorf("failed to retrive cookie")
-	}
-
-	return BookingService{
-		client:  client,
-		version: timeEditVersion,
-	}, nil
-}
-
 func printCookies(jar http.CookieJar, u string) {
 	ur, err := url.Parse(u)
 	if err != nil {
@@ -461,17 +441,20 @@ func toUsername(cid string) string {
 	return cid + "@net.chalmers.se"
 }
 
-func getBookingPeriod(tr *goquery.Selection, selectedDate string) (time.Time, time.Time, error) {
+func getBookingPeriod(tr *goquery.Selection, selectedDate string, location *time.Location) (time.Time, time.Time, error) {
 	timeInfo := tr.Find(".time").Text()
 
 	timeStrings := strings.Split(timeInfo, " - ")
-	startTime, err := time.Parse("2006-01-02T15:04", fmt.Sprintf("%sT%s", selectedDate, timeStrings[0]))
+	if len(timeStrings) != 2 {
+		return time.Time{}, time.Time{}, parseError(fmt.Errorf("invalid booking period %q", timeInfo))
+	}
+	startTime, err := time.ParseInLocation("2006-01-02T15:04", fmt.Sprintf("%sT%s", selectedDate, timeStrings[0]), location)
 	if err != nil {
-		return time.Time{}, time.Time{}, fmt.Errorf("parsing failure")
+		return time.Time{}, time.Time{}, parseError(err)
 	}
-	endTime, err := time.Parse("2006-01-02T15:04", fmt.Sprintf("%sT%s", selectedDate, timeStrings[1]))
+	endTime, err := time.ParseInLocation("2006-01-02T15:04", fmt.Sprintf("%sT%s", selectedDate, timeStrings[1]), location)
 	if err != nil {
-		return time.Time{}, time.Time{}, fmt.Errorf("parsing failure")
+		return time.Time{}, time.Time{}, parseError(err)
 	}
 	return startTime, endTime, nil
 }
@@ -0,0 +1,108 @@
+package timeedit
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/parallel"
+)
+
+// The number of booking texts fetched at the same time
+const maxConcurrentTexts = 4
+
+const textColumnName = "Egen text"
+
+var columnClass = regexp.MustCompile(`\bcolumn\d+\b`)
+
+// parseBookings reads the bookings from the list of bookings. It also
+// tells if the list included the texts of the bookings.
+func (bs BookingService) parseBookings(doc *goquery.Document) ([]booking.Booking, bool, error) {
+	// Find the table with the bookings in
+	selections := doc.Find("body #texttable table tr")
+
+	// The important information starts on the third row
+	if selections.Length() < 2 {
+		return nil, false, parseError(fmt.Errorf("bookings table not found"))
+	}
+	textColumn, hasTexts := findTextColumn(selections.Slice(0, 2))
+
+	bookings := make([]booking.Booking, 0, 4)
+	selectedDate := ""
+	for i := 2; i < selections.Length(); i++ {
+		tr := selections.Eq(i)
+
+		// Check if the row is a date row
+		headline := tr.Find(".headline.t")
+		if headline.Length() > 0 {
+			// If it is a date row we extract the date and move to the next row
+			parts := strings.Split(headline.Text(), " ")
+			if len(parts) < 2 {
+				return nil, false, parseError(fmt.Errorf("invalid date row %q", headline.Text()))
+			}
+			selectedDate = parts[1]
+			continue
+		}
+
+		// If it isn't and we have no selected date somethings wrong
+		if selectedDate == "" {
+			return nil, false, parseError(fmt.Errorf("booking without a date"))
+		}
+		id, found := tr.Attr("data-id")
+		if !found {
+			return nil, false, parseError(fmt.Errorf("booking without an id"))
+		}
+
+		roomInfo := strings.Split(tr.Find(".column0").Text(), ", ")[0]
+
+		startTime, endTime, err := getBookingPeriod(tr, selectedDate, bs.location)
+		if err != nil {
+			return nil, false, err
+		}
+
+		text := ""
+		if hasTexts {
+			text = strings.TrimSpace(tr.Find("." + textColumn).Text())
+		}
+
+		bookings = append(bookings, booking.Booking{
+			Text:  text,
+			Start: startTime,
+			End:   endTime,
+			Room: booking.Room{
+				Provider: bs.Provider(),
+				Id:       roomInfo,
+			},
+			Id: id,
+		})
+	}
+	return bookings, hasTexts, nil
+}
+
+// findTextColumn looks for the column with the booking texts among the
+// header rows of the list of bookings and returns its class
+func findTextColumn(headers *goquery.Selection) (string, bool) {
+	column := ""
+	headers.Find("[class*=column]").EachWithBreak(func(i int, cell *goquery.Selection) bool {
+		if strings.TrimSpace(cell.Text()) != textColumnName {
+			return true
+		}
+		class, _ := cell.Attr("class")
+		column = columnClass.FindString(class)
+		return column == ""
+	})
+	return column, column != ""
+}
+
+// fillTexts fetches the texts of the bookings, a few at a time
+func (bs BookingService) fillTexts(ctx context.Context, bookings []booking.Booking) error {
+	return parallel.Do(ctx, len(bookings), maxConcurrentTexts, func(ctx context.Context, i int) error {
+		var err error
+		bookings[i].Text, err = bs.getText(ctx, bookings[i].Id)
+		return err
+	})
+}
@@ -0,0 +1,98 @@
+package timeedit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+const bookingsPage = `<html><body><div id="texttable"><table>
+<tr><td class="headline">Mina bokningar</td></tr>
+<tr><th class="column0">Lokal</th><th class="time">Tid</th><th class="column1 pr">%s</th></tr>
+<tr><td class="headline t">Tis 2020-09-15</td></tr>
+<tr data-id="1001"><td class="column0">EG-2515, Johanneberg</td><td class="time">10:00 - 12:00</td><td class="column1 pr"> Study group </td></tr>
+<tr data-id="1002"><td class="column0">SB-G311, Johanneberg</td><td class="time">13:15 - 15:00</td><td class="column1 pr"></td></tr>
+</table></div></body></html>`
+
+func parseTestPage(t *testing.T, textHeader string) *goquery.Document {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(strings.Replace(bookingsPage, "%s", textHeader, 1)))
+	assert.NoError(t, err)
+	return doc
+}
+
+func TestBookingService_parseBookings(t *testing.T) {
+	bs := BookingService{instance: Chalmers, location: stockholm(t)}
+
+	bookings, hasTexts, err := bs.parseBookings(parseTestPage(t, "Egen text"))
+	assert.NoError(t, err)
+	assert.True(t, hasTexts)
+	assert.Len(t, bookings, 2)
+	assert.Equal(t, "1001", bookings[0].Id)
+	assert.Equal(t, "EG-2515", bookings[0].Room.Id)
+	assert.Equal(t, bs.Provider(), bookings[0].Room.Provider)
+	assert.True(t, time.Date(2020, 9, 15, 8, 0, 0, 0, time.UTC).Equal(bookings[0].Start), "Times should be in Swedish summer time")
+	assert.True(t, time.Date(2020, 9, 15, 10, 0, 0, 0, time.UTC).Equal(bookings[0].End))
+	assert.Equal(t, "Study group", bookings[0].Text)
+	assert.Equal(t, "", bookings[1].Text)
+
+	bookings, hasTexts, err = bs.parseBookings(parseTestPage(t, "Syfte"))
+	assert.NoError(t, err)
+	assert.False(t, hasTexts, "Texts should only be read from a column named after them")
+	assert.Len(t, bookings, 2)
+	assert.Equal(t, "", bookings[0].Text)
+}
+
+func TestBookingService_parseBookingsInvalid(t *testing.T) {
+	bs := BookingService{instance: Chalmers, location: stockholm(t)}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<html><body></body></html>"))
+	assert.NoError(t, err)
+	_, _, err = bs.parseBookings(doc)
+	assert.Error(t, err)
+
+	page := strings.Replace(bookingsPage, `<tr><td class="headline t">Tis 2020-09-15</td></tr>`, "", 1)
+	doc, err = goquery.NewDocumentFromReader(strings.NewReader(page))
+	assert.NoError(t, err)
+	_, _, err = bs.parseBookings(doc)
+	assert.Error(t, err, "Bookings without a date shouldn't be accepted")
+}
+
+func TestBookingService_UnBookRetried(t *testing.T) {
+	removed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			if removed {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			// The booking is removed but the response doesn't make it
+			removed = true
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		page := strings.Replace(bookingsPage, "%s", "Egen text", 1)
+		if removed {
+			page = strings.Replace(page, `<tr data-id="1001">`, `<tr data-id="1003">`, 1)
+		}
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	instance := Instance{Name: "test", BaseURL: server.URL}
+	s, err := newSession(instance, "", nil, nil, NewTransport(http.DefaultTransport, testTransportOptions()))
+	assert.NoError(t, err)
+	bs := BookingService{session: s, instance: instance, location: time.UTC}
+
+	assert.NoError(t, bs.UnBook(context.Background(), booking.Booking{Id: "1001"}),
+		"The booking is gone, so removing it succeeded")
+	assert.Error(t, bs.UnBook(context.Background(), booking.Booking{Id: "1002"}),
+		"The booking is still there, so removing it failed")
+}
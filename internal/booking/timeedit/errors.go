@@ -0,0 +1,72 @@
+package timeedit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+// Phrases in the messages TimeEdit responds with when a reservation is
+// refused, mapped to the kind of failure they describe.
+var refusalPhrases = []struct {
+	phrase string
+	kind   booking.Error
+}{
+	{"upptagen", booking.ErrRoomUnavailable},
+	{"krock", booking.ErrRoomUnavailable},
+	{"redan bokad", booking.ErrRoomUnavailable},
+	{"already booked", booking.ErrRoomUnavailable},
+	{"collision", booking.ErrRoomUnavailable},
+	{"maximalt antal", booking.ErrQuotaExceeded},
+	{"max antal", booking.ErrQuotaExceeded},
+	{"för många", booking.ErrQuotaExceeded},
+	{"maximum number", booking.ErrQuotaExceeded},
+	{"too many", booking.ErrQuotaExceeded},
+	{"bokningsfönst", booking.ErrOutsideBookingWindow},
+	{"för långt fram", booking.ErrOutsideBookingWindow},
+	{"passerad", booking.ErrOutsideBookingWindow},
+	{"booking window", booking.ErrOutsideBookingWindow},
+	{"too far", booking.ErrOutsideBookingWindow},
+	{"in the past", booking.ErrOutsideBookingWindow},
+}
+
+// statusError maps an unexpected response status onto the booking errors
+func statusError(status int, action string) error {
+	return withStatusKind(status, fmt.Errorf("failed to %s (%d)", action, status))
+}
+
+// refusalError maps the message of a refused reservation onto the booking
+// errors, falling back to the status of the response.
+func refusalError(status int, message string) error {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return statusError(status, "book room")
+	}
+
+	lower := strings.ToLower(message)
+	for _, p := range refusalPhrases {
+		if strings.Contains(lower, p.phrase) {
+			return booking.Wrap(p.kind, errors.New(message))
+		}
+	}
+	return withStatusKind(status, errors.New(message))
+}
+
+func withStatusKind(status int, err error) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return booking.Wrap(booking.ErrAuthenticationFailed, err)
+	case status >= 500:
+		return booking.Wrap(booking.ErrProviderUnreachable, err)
+	default:
+		return err
+	}
+}
+
+// parseError marks err as a failure to understand a response from TimeEdit
+func parseError(err error) error {
+	return booking.Wrap(booking.ErrParseFailure, err)
+}
@@ -0,0 +1,31 @@
+package timeedit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+func TestRefusalError(t *testing.T) {
+	tests := []struct {
+		status  int
+		message string
+		want    error
+	}{
+		{400, "Lokalen är upptagen den valda tiden", booking.ErrRoomUnavailable},
+		{400, "Du har bokat maximalt antal tillfällen", booking.ErrQuotaExceeded},
+		{400, "Tiden ligger utanför bokningsfönstret", booking.ErrOutsideBookingWindow},
+		{403, "Åtkomst nekad", booking.ErrAuthenticationFailed},
+		{502, "", booking.ErrProviderUnreachable},
+	}
+	for _, tt := range tests {
+		err := refusalError(tt.status, tt.message)
+		assert.True(t, errors.Is(err, tt.want), "%q should be %v, got %v", tt.message, tt.want, err)
+	}
+
+	err := refusalError(400, "Something unexpected")
+	assert.EqualError(t, err, "Something unexpected")
+}
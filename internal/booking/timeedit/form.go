@@ -1,6 +1,7 @@
 package timeedit
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -17,14 +18,14 @@ func getForm(resp *http.Response, selector string) (form, error) {
 	// Extract the form element
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
-		return form{}, err
+		return form{}, parseError(err)
 	}
 	formElement := doc.Find(fmt.Sprintf("body %s", selector))
 
 	// Get the action url for submitting the form
 	formAction, found := formElement.Attr("action")
-	if !found {
-		return form{}, err
+	if !found || formAction == "" {
+		return form{}, parseError(fmt.Errorf("form %s not found", selector))
 	}
 
 	// Build url for relative paths
@@ -50,6 +51,6 @@ func getForm(resp *http.Response, selector string) (form, error) {
 	}, nil
 }
 
-func (f *form) Post(client *http.Client) (*http.Response, error) {
-	return client.PostForm(f.Action, f.Values)
+func (f *form) Post(ctx context.Context, client *http.Client) (*http.Response, error) {
+	return postForm(ctx, client, f.Action, f.Values)
 }
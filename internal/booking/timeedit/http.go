@@ -0,0 +1,49 @@
+package timeedit
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+func newGetRequest(ctx context.Context, u string) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, "GET", u, nil)
+}
+
+func newPostFormRequest(ctx context.Context, u string, data url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", u, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+func get(ctx context.Context, client *http.Client, u string) (*http.Response, error) {
+	req, err := newGetRequest(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	return do(client, req)
+}
+
+func postForm(ctx context.Context, client *http.Client, u string, data url.Values) (*http.Response, error) {
+	req, err := newPostFormRequest(ctx, u, data)
+	if err != nil {
+		return nil, err
+	}
+	return do(client, req)
+}
+
+// do sends the request, marking failures to reach TimeEdit as such unless
+// they are caused by the context being cancelled.
+func do(client *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil && req.Context().Err() == nil {
+		return nil, booking.Wrap(booking.ErrProviderUnreachable, err)
+	}
+	return resp, err
+}
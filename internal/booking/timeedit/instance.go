@@ -0,0 +1,125 @@
+package timeedit
+
+import (
+	"fmt"
+	"strings"
+)
+
+const defaultBaseURL = "https://cloud.timeedit.net"
+const defaultSSOPath = "timeedit/sso/saml2"
+const defaultRoomType = "186"
+const defaultPurpose = "203460.192"
+const defaultTimeZone = "Europe/Stockholm"
+
+// The page bookings are made from
+const bookPage = "ri1Q5008.html"
+
+// Instance describes a TimeEdit instance and how rooms are found and booked
+// in it. Empty fields use the values of the Chalmers instances.
+type Instance struct {
+	// Name of the instance in the urls of TimeEdit, like chalmers
+	Name string `mapstructure:"name"`
+	// BaseURL is where TimeEdit is hosted
+	BaseURL string `mapstructure:"base_url"`
+	// SSOPath is the path of the single sign on, relative to the web folder
+	// of the instance
+	SSOPath string `mapstructure:"sso_path"`
+	// RoomType is the id of the object type of rooms
+	RoomType string `mapstructure:"room_type"`
+	// Purpose is the id of the object given as the purpose of bookings
+	Purpose string `mapstructure:"purpose"`
+	// Filters are added to every search for rooms, like sid=1010
+	Filters []string `mapstructure:"filters"`
+	// Exclusions are filters matching rooms which shouldn't be booked
+	Exclusions []string `mapstructure:"exclusions"`
+	// TimeZone is the time zone TimeEdit shows and expects times in, as
+	// named in the IANA time zone database
+	TimeZone string `mapstructure:"time_zone"`
+}
+
+// Chalmers is where the rooms of the student union are booked
+var Chalmers = Instance{
+	Name:    "chalmers",
+	Filters: []string{"sid=1010"},
+}
+
+// ChalmersCovid is where the rooms of the university are booked, the rooms
+// of the student union are also listed but can't be booked there
+var ChalmersCovid = Instance{
+	Name:       "chalmers_covid",
+	SSOPath:    "timeedit/sso/saml2_covid",
+	Exclusions: []string{"sid=1010"},
+}
+
+// DefaultInstances are the instances used unless others are configured
+func DefaultInstances() []Instance {
+	return []Instance{Chalmers, ChalmersCovid}
+}
+
+func (i Instance) String() string {
+	return i.Name
+}
+
+// withDefaults fills in the empty fields and makes sure the instance can
+// be used
+func (i Instance) withDefaults() (Instance, error) {
+	if i.Name == "" {
+		return i, fmt.Errorf("timeedit instance without a name")
+	}
+	if i.BaseURL == "" {
+		i.BaseURL = defaultBaseURL
+	}
+	i.BaseURL = strings.TrimRight(i.BaseURL, "/")
+	if i.SSOPath == "" {
+		i.SSOPath = defaultSSOPath
+	}
+	i.SSOPath = strings.TrimLeft(i.SSOPath, "/")
+	if i.RoomType == "" {
+		i.RoomType = defaultRoomType
+	}
+	if i.Purpose == "" {
+		i.Purpose = defaultPurpose
+	}
+	if i.TimeZone == "" {
+		i.TimeZone = defaultTimeZone
+	}
+	return i, nil
+}
+
+// webURL is the folder every page of the instance is in
+func (i Instance) webURL() string {
+	return fmt.Sprintf("%s/%s/web/b1/", i.BaseURL, i.Name)
+}
+
+func (i Instance) samlURL() string {
+	return fmt.Sprintf("%s/%s/web/%s?back=%s", i.BaseURL, i.Name, i.SSOPath, i.webURL())
+}
+
+func (i Instance) bookURL() string {
+	return i.webURL() + bookPage
+}
+
+func (i Instance) bookingsURL() string {
+	return i.webURL() + "my.html"
+}
+
+// objectsURL is used to search for rooms, extra is added to the query
+// together with the filters of the instance
+func (i Instance) objectsURL(extra ...string) string {
+	u := fmt.Sprintf("%sobjects.json?part=t&types=%s&step=1", i.webURL(), i.RoomType)
+	for _, e := range append(extra, i.Filters...) {
+		if e != "" {
+			u += "&" + strings.TrimLeft(e, "&")
+		}
+	}
+	return u
+}
+
+func (i Instance) cookiePath() string {
+	return fmt.Sprintf("/%s/web/", i.Name)
+}
+
+// cookieName is the name of the cookie of a logged in session
+func (i Instance) cookieName() string {
+	return fmt.Sprintf("TE%sweb", i.Name)
+}
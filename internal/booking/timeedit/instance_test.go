@@ -0,0 +1,45 @@
+package timeedit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstance_URLs(t *testing.T) {
+	chalmers, err := Chalmers.withDefaults()
+	assert.NoError(t, err)
+	covid, err := ChalmersCovid.withDefaults()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "https://cloud.timeedit.net/chalmers/web/timeedit/sso/saml2?back=https://cloud.timeedit.net/chalmers/web/b1/",
+		chalmers.samlURL())
+	assert.Equal(t, "https://cloud.timeedit.net/chalmers_covid/web/timeedit/sso/saml2_covid?back=https://cloud.timeedit.net/chalmers_covid/web/b1/",
+		covid.samlURL())
+	assert.Equal(t, "https://cloud.timeedit.net/chalmers/web/b1/ri1Q5008.html", chalmers.bookURL())
+	assert.Equal(t, "https://cloud.timeedit.net/chalmers/web/b1/my.html", chalmers.bookingsURL())
+	assert.Equal(t, "TEchalmers_covidweb", covid.cookieName())
+	assert.Equal(t, "/chalmers_covid/web/", covid.cookiePath())
+
+	assert.Equal(t, "https://cloud.timeedit.net/chalmers/web/b1/objects.json?part=t&types=186&step=1&dates=20200928-20200928&sid=1010",
+		chalmers.objectsURL("dates=20200928-20200928"))
+	assert.Equal(t, "https://cloud.timeedit.net/chalmers_covid/web/b1/objects.json?part=t&types=186&step=1&sid=1010",
+		covid.objectsURL("", "sid=1010"))
+}
+
+func TestInstance_withDefaults(t *testing.T) {
+	_, err := Instance{}.withDefaults()
+	assert.Error(t, err, "Instances need a name")
+
+	gu, err := Instance{
+		Name:     "gu",
+		BaseURL:  "https://timeedit.example.com/",
+		SSOPath:  "/sso/gu",
+		RoomType: "4",
+		Filters:  []string{"&fr=t"},
+	}.withDefaults()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://timeedit.example.com/gu/web/sso/gu?back=https://timeedit.example.com/gu/web/b1/", gu.samlURL())
+	assert.Equal(t, "https://timeedit.example.com/gu/web/b1/objects.json?part=t&types=4&step=1&fr=t", gu.objectsURL())
+	assert.Equal(t, defaultPurpose, gu.Purpose)
+}
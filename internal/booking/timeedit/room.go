@@ -1,6 +1,6 @@
 package timeedit
 
-import "fmt"
+import "sidus.io/boogrocha/internal/booking"
 
 type room struct {
 	Name   string `json:"fields.Lokalsignatur"`
@@ -17,7 +17,7 @@ func (rs rooms) idFromName(name string) (string, error) {
 			return room.Id, nil
 		}
 	}
-	return "", fmt.Errorf("no such room")
+	return "", booking.ErrNoSuchRoom
 }
 
 func (rs rooms) nameFromId(id string) (string, error) {
@@ -26,7 +26,7 @@ func (rs rooms) nameFromId(id string) (string, error) {
 			return room.Name, nil
 		}
 	}
-	return "", fmt.Errorf("no such room")
+	return "", booking.ErrNoSuchRoom
 }
 
 func (rs rooms) removeAt(i int) rooms {
@@ -48,3 +48,18 @@ func (rs rooms) removeMany(rooms rooms) rooms {
 	}
 	return rs
 }
+
+// intersect returns the rooms which are in both rs and other
+func (rs rooms) intersect(other rooms) rooms {
+	names := make(map[string]bool, len(other))
+	for _, r := range other {
+		names[r.Name] = true
+	}
+	var result rooms
+	for _, r := range rs {
+		if names[r.Name] {
+			result = append(result, r)
+		}
+	}
+	return result
+}
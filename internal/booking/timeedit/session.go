@@ -0,0 +1,275 @@
+package timeedit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/publicsuffix"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+// session keeps the cookies of a logged in user and logs in again when
+// TimeEdit considers them expired.
+type session struct {
+	instance Instance
+	cid      string
+	password func() string
+	store    SessionStore
+
+	jar *cookiejar.Jar
+	// client is used for every request but the login flow and stops
+	// following redirects when TimeEdit redirects to a login page
+	client      *http.Client
+	loginClient *http.Client
+
+	mutex sync.Mutex
+	// generation is increased on every login so that concurrent requests
+	// finding the session expired only log in once
+	generation int
+}
+
+func newSession(instance Instance, cid string, password func() string, store SessionStore, transport http.RoundTripper) (*session, error) {
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return nil, err
+	}
+	return &session{
+		instance: instance,
+		cid:      cid,
+		password: password,
+		store:    store,
+		jar:      jar,
+		client: &http.Client{
+			Transport: transport,
+			Jar:       jar,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if isLoginURL(via[0].URL, req.URL) {
+					return http.ErrUseLastResponse
+				}
+				if len(via) >= 10 {
+					return fmt.Errorf("stopped after 10 redirects")
+				}
+				return nil
+			},
+		},
+		loginClient: &http.Client{
+			Transport: transport,
+			Jar:       jar,
+		},
+	}, nil
+}
+
+func (s *session) storeName() string {
+	return fmt.Sprintf("%s_%s", s.instance.Name, s.cid)
+}
+
+func (s *session) url() *url.URL {
+	u, _ := url.Parse(s.instance.webURL())
+	return u
+}
+
+// restore loads the cookies of a previous session from the store, it
+// returns false if there was no session to restore.
+func (s *session) restore() (bool, error) {
+	if s.store == nil {
+		return false, nil
+	}
+	cookies, err := s.store.Load(s.storeName())
+	if err != nil || len(cookies) == 0 {
+		return false, err
+	}
+
+	for _, cookie := range cookies {
+		cookie.Path = s.instance.cookiePath()
+	}
+	s.jar.SetCookies(s.url(), cookies)
+	return true, nil
+}
+
+// ensure makes sure the session is logged in, restoring a stored session
+// if there is one.
+func (s *session) ensure(ctx context.Context) error {
+	restored, err := s.restore()
+	if err == nil && restored {
+		return nil
+	}
+	return s.relogin(ctx, s.current())
+}
+
+func (s *session) current() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.generation
+}
+
+// relogin logs in again unless someone else already did so since the
+// given generation of the session was used.
+func (s *session) relogin(ctx context.Context, generation int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if generation != s.generation {
+		return nil
+	}
+
+	err := s.login(ctx)
+	if err != nil {
+		if s.store != nil {
+			_ = s.store.Clear(s.storeName())
+		}
+		return err
+	}
+	s.generation++
+
+	if s.store != nil {
+		err = s.store.Save(s.storeName(), s.jar.Cookies(s.url()))
+		if err != nil {
+			return fmt.Errorf("couldn't save session: %w", err)
+		}
+	}
+	return nil
+}
+
+// do sends the request, logging in again and resending the request once
+// if TimeEdit redirects to a login page.
+func (s *session) do(req *http.Request) (*http.Response, error) {
+	generation := s.current()
+
+	resp, err := do(s.client, req)
+	if err != nil || !isLoginRedirect(resp) {
+		return resp, err
+	}
+	_ = resp.Body.Close()
+
+	err = s.relogin(req.Context(), generation)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err = do(s.client, retry)
+	if err == nil && isLoginRedirect(resp) {
+		_ = resp.Body.Close()
+		return nil, booking.Wrap(booking.ErrAuthenticationFailed, fmt.Errorf("session expired right after logging in"))
+	}
+	return resp, err
+}
+
+func (s *session) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := newGetRequest(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	return s.do(req)
+}
+
+func (s *session) postForm(ctx context.Context, u string, data url.Values) (*http.Response, error) {
+	req, err := newPostFormRequest(ctx, u, data)
+	if err != nil {
+		return nil, err
+	}
+	return s.do(req)
+}
+
+func (s *session) login(ctx context.Context) error {
+	// Initiate SAML auth flow
+	resp, err := get(ctx, s.loginClient, s.instance.samlURL())
+	if err != nil {
+		return err
+	}
+
+	// Extract login form from request to cover XSS prevention values
+	form, err := getForm(resp, "#loginForm")
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	// Populate form with username and password
+	form.Values.Add("UserName", toUsername(s.cid))
+	form.Values.Add("Password", s.password())
+
+	// Submit login form
+	resp, err = form.Post(ctx, s.loginClient)
+	if err != nil {
+		return err
+	}
+
+	// The IDP responds with a form that redirects to the original site,
+	// this form is usually auto submitted by a script snippet but we have to submit it ourselves
+	form, err = getForm(resp, "form")
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	// Check if login was successful
+	success := false
+	for key := range form.Values {
+		if key == "SAMLResponse" {
+			success = true
+			break
+		}
+	}
+	if !success {
+		return booking.ErrAuthenticationFailed
+	}
+
+	// Submit the redirect form
+	resp, err = form.Post(ctx, s.loginClient)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	// Check that we got the auth cookie
+	u, err := url.Parse(form.Action)
+	if err != nil {
+		return err
+	}
+	success = false
+	for _, cookie := range s.jar.Cookies(u) {
+		if cookie.Name == s.instance.cookieName() {
+			success = true
+			break
+		}
+	}
+	if !success {
+		return booking.Wrap(booking.ErrAuthenticationFailed, fmt.Errorf("failed to retrieve cookie"))
+	}
+
+	return nil
+}
+
+// isLoginRedirect tells if TimeEdit responded by redirecting to a login page,
+// which happens when the session has expired.
+func isLoginRedirect(resp *http.Response) bool {
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return false
+	}
+	location, err := resp.Location()
+	return err == nil && isLoginURL(resp.Request.URL, location)
+}
+
+// isLoginURL tells if a redirect from a request to TimeEdit leads to a login
+// page, either at TimeEdit or at the identity provider.
+func isLoginURL(from *url.URL, to *url.URL) bool {
+	if to.Host != from.Host {
+		return true
+	}
+	path := strings.ToLower(to.Path)
+	return strings.Contains(path, "/sso/") || strings.Contains(path, "login")
+}
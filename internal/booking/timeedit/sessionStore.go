@@ -0,0 +1,116 @@
+package timeedit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SessionStore persists the cookies of logged in sessions between runs
+type SessionStore interface {
+	Load(name string) ([]*http.Cookie, error)
+	Save(name string, cookies []*http.Cookie) error
+	Clear(name string) error
+}
+
+type storedCookie struct {
+	Name  string
+	Value string
+}
+
+type storedSession struct {
+	Expires time.Time
+	Cookies []storedCookie
+}
+
+// FileSessionStore stores every session in its own file which only the
+// current user can read. Sessions are forgotten after lifetime has passed.
+type FileSessionStore struct {
+	path     string
+	lifetime time.Duration
+}
+
+func NewFileSessionStore(path string, lifetime time.Duration) (*FileSessionStore, error) {
+	// Create folder if it doesnt exist
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		err = os.MkdirAll(path, 0700)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &FileSessionStore{
+		path:     path,
+		lifetime: lifetime,
+	}, nil
+}
+
+func (fs *FileSessionStore) file(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(name)
+	return filepath.Join(fs.path, name+".json")
+}
+
+func (fs *FileSessionStore) Load(name string) ([]*http.Cookie, error) {
+	bytes, err := ioutil.ReadFile(fs.file(name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session storedSession
+	err = json.Unmarshal(bytes, &session)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(session.Expires) {
+		return nil, fs.Clear(name)
+	}
+
+	var cookies []*http.Cookie
+	for _, c := range session.Cookies {
+		cookies = append(cookies, &http.Cookie{
+			Name:  c.Name,
+			Value: c.Value,
+		})
+	}
+	return cookies, nil
+}
+
+func (fs *FileSessionStore) Save(name string, cookies []*http.Cookie) error {
+	session := storedSession{
+		Expires: time.Now().Add(fs.lifetime),
+	}
+	for _, c := range cookies {
+		session.Cookies = append(session.Cookies, storedCookie{
+			Name:  c.Name,
+			Value: c.Value,
+		})
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	// Make sure no one else can read the session, WriteFile only applies
+	// the permissions when creating the file
+	err = ioutil.WriteFile(fs.file(name), data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(fs.file(name), 0600)
+}
+
+func (fs *FileSessionStore) Clear(name string) error {
+	err := os.Remove(fs.file(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+package timeedit
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSessionStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sessions")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileSessionStore(filepath.Join(dir, "sessions"), time.Hour)
+	assert.NoError(t, err)
+
+	cookies, err := store.Load("chalmers_abc")
+	assert.NoError(t, err)
+	assert.Empty(t, cookies, "Missing sessions should load as empty")
+
+	err = store.Save("chalmers_abc", []*http.Cookie{{Name: "TEchalmersweb", Value: "secret"}})
+	assert.NoError(t, err)
+
+	info, err := os.Stat(store.file("chalmers_abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "Sessions should only be readable by the user")
+
+	cookies, err = store.Load("chalmers_abc")
+	assert.NoError(t, err)
+	assert.Equal(t, []*http.Cookie{{Name: "TEchalmersweb", Value: "secret"}}, cookies)
+
+	assert.NoError(t, store.Clear("chalmers_abc"))
+	cookies, err = store.Load("chalmers_abc")
+	assert.NoError(t, err)
+	assert.Empty(t, cookies)
+}
+
+func TestFileSessionStoreExpiry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sessions")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileSessionStore(dir, -time.Minute)
+	assert.NoError(t, err)
+
+	err = store.Save("chalmers_abc", []*http.Cookie{{Name: "TEchalmersweb", Value: "secret"}})
+	assert.NoError(t, err)
+
+	cookies, err := store.Load("chalmers_abc")
+	assert.NoError(t, err)
+	assert.Empty(t, cookies, "Expired sessions should not be loaded")
+
+	_, err = os.Stat(store.file("chalmers_abc"))
+	assert.True(t, os.IsNotExist(err), "Expired sessions should be removed")
+}
+
+func TestIsLoginRedirect(t *testing.T) {
+	redirect := func(status int, location string) *http.Response {
+		req, _ := http.NewRequest("GET", "https://cloud.timeedit.net/chalmers/web/b1/my.html", nil)
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Location": []string{location}},
+			Request:    req,
+		}
+	}
+
+	assert.True(t, isLoginRedirect(redirect(302, "https://cloud.timeedit.net/chalmers/web/timeedit/sso/saml2")))
+	assert.True(t, isLoginRedirect(redirect(302, "/chalmers/web/b1/login.html")))
+	assert.False(t, isLoginRedirect(redirect(302, "/chalmers/web/b1/my.html")))
+	assert.True(t, isLoginRedirect(redirect(302, "https://idp.chalmers.se/adfs/ls/?SAMLRequest=x")))
+	assert.False(t, isLoginRedirect(redirect(200, "")))
+}
@@ -0,0 +1,50 @@
+package timeedit
+
+import (
+	"context"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/parallel"
+)
+
+// Number of slots that are requested from TimeEdit at the same time
+const maxConcurrentSlots = 4
+
+// Timeline returns the availability of every room in the instance between
+// start and end. Unlike asking for the available rooms of each slot this
+// also includes the rooms which are busy during the whole interval.
+func (bs BookingService) Timeline(ctx context.Context, start time.Time, end time.Time, step time.Duration) ([]booking.Timeline, error) {
+	slots := booking.Slots(start, end, step)
+	free := make([]map[string]bool, len(slots))
+	err := parallel.Do(ctx, len(slots), maxConcurrentSlots, func(ctx context.Context, i int) error {
+		slot := slots[i]
+		rs, err := bs.fetchRooms(ctx, bs.instance.objectsURL(availabilityQuery(slot.In(bs.location), slot.Add(step).In(bs.location))))
+		if err != nil {
+			return err
+		}
+		free[i] = make(map[string]bool)
+		for _, r := range rs {
+			free[i][r.Name] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timelines := make([]booking.Timeline, 0, len(bs.rooms))
+	for _, r := range bs.rooms {
+		t := booking.Timeline{
+			Room:  bs.bookingRoom(r),
+			Start: start,
+			Step:  step,
+			Free:  make([]bool, len(slots)),
+		}
+		for i := range slots {
+			t.Free[i] = free[i][r.Name]
+		}
+		timelines = append(timelines, t)
+	}
+	return timelines, nil
+}
@@ -0,0 +1,99 @@
+package timeedit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+func stockholm(t *testing.T) *time.Location {
+	location, err := time.LoadLocation(defaultTimeZone)
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	return location
+}
+
+func TestGetBookingPeriod_DaylightSaving(t *testing.T) {
+	location := stockholm(t)
+
+	tests := []struct {
+		name     string
+		date     string
+		period   string
+		duration time.Duration
+		offset   int
+	}{
+		// Sweden switched to summer time at 02:00 on the 29th of March 2020
+		{"before summer time", "2020-03-28", "01:00 - 04:00", 3 * time.Hour, 1 * 60 * 60},
+		{"into summer time", "2020-03-29", "01:00 - 04:00", 2 * time.Hour, 1 * 60 * 60},
+		// and back to winter time at 03:00 on the 25th of October 2020
+		{"summer time", "2020-10-24", "10:00 - 12:00", 2 * time.Hour, 2 * 60 * 60},
+		{"out of summer time", "2020-10-25", "01:00 - 04:00", 4 * time.Hour, 2 * 60 * 60},
+		{"winter time", "2020-10-26", "10:00 - 12:00", 2 * time.Hour, 1 * 60 * 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(
+				`<table><tr><td class="time">` + tt.period + `</td></tr></table>`))
+			assert.NoError(t, err)
+
+			start, end, err := getBookingPeriod(doc.Find("tr"), tt.date, location)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.duration, end.Sub(start))
+			_, offset := start.Zone()
+			assert.Equal(t, tt.offset, offset)
+			assert.Equal(t, strings.Split(tt.period, " - ")[0], start.Format("15:04"))
+		})
+	}
+}
+
+func TestBookingForm_TimeZone(t *testing.T) {
+	bs := BookingService{
+		instance: Chalmers,
+		location: stockholm(t),
+		rooms:    rooms{{Name: "EG-2515", Id: "123.186"}},
+	}
+
+	tests := []struct {
+		name      string
+		start     time.Time
+		date      string
+		startTime string
+		endTime   string
+	}{
+		{"summer time", time.Date(2020, 10, 24, 8, 0, 0, 0, time.UTC), "20201024", "10:00", "12:00"},
+		{"winter time", time.Date(2020, 10, 26, 8, 0, 0, 0, time.UTC), "20201026", "09:00", "11:00"},
+		{"other time zone", time.Date(2020, 10, 26, 3, 0, 0, 0, time.FixedZone("EST", -5*60*60)), "20201026", "09:00", "11:00"},
+		{"next day in sweden", time.Date(2020, 10, 26, 23, 30, 0, 0, time.UTC), "20201027", "00:30", "02:30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form, err := bs.BookingForm(booking.Booking{
+				Room:  booking.Room{Id: "EG-2515"},
+				Start: tt.start,
+				End:   tt.start.Add(2 * time.Hour),
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.date, form.Get("dates"))
+			assert.Equal(t, tt.startTime, form.Get("starttime"))
+			assert.Equal(t, tt.endTime, form.Get("endtime"))
+		})
+	}
+}
+
+func TestSplitAtMidnight_TimeZone(t *testing.T) {
+	location := stockholm(t)
+
+	// 21:00 to 23:00 UTC spans midnight in Sweden
+	start := time.Date(2020, 9, 28, 21, 0, 0, 0, time.UTC).In(location)
+	parts := splitAtMidnight(start, start.Add(2*time.Hour))
+	assert.Len(t, parts, 2)
+	assert.Equal(t, "dates=20200928-20200928&starttime=23:00&endtime=24:00", availabilityQuery(parts[0][0], parts[0][1]))
+	assert.Equal(t, "dates=20200929-20200929&starttime=00:00&endtime=01:00", availabilityQuery(parts[1][0], parts[1][1]))
+}
@@ -0,0 +1,259 @@
+package timeedit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TransportOptions configures how requests to TimeEdit are retried and
+// rate limited
+type TransportOptions struct {
+	// MaxRetries is the number of times a failed request is retried
+	MaxRetries int
+	// MinBackoff is the wait before the first retry, it doubles for every
+	// retry until it reaches MaxBackoff. A random part of it is added to
+	// spread out the retries of concurrent requests.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// RequestsPerSecond limits the requests sent to each host with a token
+	// bucket which allows bursts of Burst requests. Zero disables the limit.
+	RequestsPerSecond float64
+	Burst             int
+}
+
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		MaxRetries:        3,
+		MinBackoff:        200 * time.Millisecond,
+		MaxBackoff:        5 * time.Second,
+		RequestsPerSecond: 5,
+		Burst:             10,
+	}
+}
+
+// Transport retries requests that failed in a way that is likely to be
+// temporary and limits how fast requests are sent to each host. Requests
+// that aren't idempotent, like booking a room, are only retried if they
+// never reached the server. A Transport should be shared by all TimeEdit
+// instances so that the limit applies to them together.
+type Transport struct {
+	base http.RoundTripper
+	opts TransportOptions
+
+	mutex   sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// NewTransport returns a Transport sending the requests with base, or
+// http.DefaultTransport if base is nil
+func NewTransport(base http.RoundTripper, opts TransportOptions) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		base:    base,
+		opts:    opts,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		err := t.wait(ctx, req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(req)
+
+		if attempt >= t.opts.MaxRetries || ctx.Err() != nil || !t.retryable(req, resp, err) {
+			return resp, err
+		}
+
+		backoff := t.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok && after > backoff {
+				backoff = after
+				if backoff > t.opts.MaxBackoff {
+					backoff = t.opts.MaxBackoff
+				}
+			}
+			// Read the body so that the connection can be reused
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		req, err = rewind(req)
+		if err != nil {
+			return nil, err
+		}
+
+		err = sleep(ctx, backoff)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryable tells if the request can be sent again after failing
+func (t *Transport) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		// The body can't be sent again
+		return false
+	}
+	if err != nil {
+		return idempotent(req.Method) || notSent(err)
+	}
+	if !idempotent(req.Method) {
+		// TimeEdit might already have handled the request
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// idempotent tells if sending the request again has the same effect as
+// sending it once. Removing a booking is, it's only removed once even if
+// TimeEdit saw the first attempt.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// notSent tells if the request failed before the connection was made, in
+// which case the server can't have seen it
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func (t *Transport) backoff(attempt int) time.Duration {
+	backoff := t.opts.MinBackoff
+	for i := 0; i < attempt && backoff < t.opts.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > t.opts.MaxBackoff {
+		backoff = t.opts.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// retryAfter reads how long the server asked us to wait
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// rewind returns a copy of req which can be sent again
+func rewind(req *http.Request) (*http.Request, error) {
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return retry, nil
+}
+
+func (t *Transport) wait(ctx context.Context, host string) error {
+	if t.opts.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	t.mutex.Lock()
+	bucket, ok := t.buckets[host]
+	if !ok {
+		bucket = newTokenBucket(t.opts.RequestsPerSecond, t.opts.Burst)
+		t.buckets[host] = bucket
+	}
+	t.mutex.Unlock()
+
+	return bucket.wait(ctx)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tokenBucket allows rate requests per second on average, with bursts of
+// up to burst requests
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait takes a token, waiting for one to become available if necessary
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mutex.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mutex.Unlock()
+			return nil
+		}
+		missing := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mutex.Unlock()
+
+		err := sleep(ctx, missing)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package timeedit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testTransportOptions() TransportOptions {
+	return TransportOptions{
+		MaxRetries: 3,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+	}
+}
+
+// failingServer responds with status to the first failures requests
+func failingServer(failures int32, status int, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(requests, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+}
+
+func TestTransport_RetriesGet(t *testing.T) {
+	var requests int32
+	server := failingServer(2, http.StatusServiceUnavailable, &requests)
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, testTransportOptions())}
+	resp, err := get(context.Background(), client, server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), requests)
+}
+
+func TestTransport_RetriesDelete(t *testing.T) {
+	var requests int32
+	server := failingServer(1, http.StatusBadGateway, &requests)
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, testTransportOptions())}
+	req, err := http.NewRequest(http.MethodDelete, server.URL, nil)
+	assert.NoError(t, err)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), requests)
+}
+
+func TestTransport_GivesUp(t *testing.T) {
+	var requests int32
+	server := failingServer(10, http.StatusBadGateway, &requests)
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, testTransportOptions())}
+	resp, err := get(context.Background(), client, server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode, "The last response should be returned")
+	assert.Equal(t, int32(4), requests)
+}
+
+func TestTransport_DoesNotRetryClientErrors(t *testing.T) {
+	var requests int32
+	server := failingServer(10, http.StatusNotFound, &requests)
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, testTransportOptions())}
+	resp, err := get(context.Background(), client, server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, int32(1), requests)
+}
+
+func TestTransport_DoesNotReplayPost(t *testing.T) {
+	var requests int32
+	server := failingServer(10, http.StatusServiceUnavailable, &requests)
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, testTransportOptions())}
+	resp, err := postForm(context.Background(), client, server.URL, url.Values{"o": {"room"}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), requests, "A booking might already have been made")
+}
+
+// dialFailures fails the first failures requests as if the server couldn't
+// be reached
+type dialFailures struct {
+	failures int32
+	attempts int32
+}
+
+func (d *dialFailures) RoundTrip(req *http.Request) (*http.Response, error) {
+	if atomic.AddInt32(&d.attempts, 1) <= d.failures {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestTransport_RetriesUnsentPost(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		body = r.PostForm.Get("o")
+	}))
+	defer server.Close()
+
+	base := &dialFailures{failures: 2}
+	client := &http.Client{Transport: NewTransport(base, testTransportOptions())}
+	resp, err := postForm(context.Background(), client, server.URL, url.Values{"o": {"room"}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), base.attempts)
+	assert.Equal(t, "room", body, "The body should be sent again")
+}
+
+func TestTransport_StopsWhenCancelled(t *testing.T) {
+	var requests int32
+	server := failingServer(10, http.StatusServiceUnavailable, &requests)
+	defer server.Close()
+
+	opts := testTransportOptions()
+	opts.MinBackoff = time.Hour
+	opts.MaxBackoff = time.Hour
+	client := &http.Client{Transport: NewTransport(nil, opts)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := get(ctx, client, server.URL)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, int32(1), requests)
+}
+
+func TestTransport_RateLimit(t *testing.T) {
+	var requests int32
+	server := failingServer(0, http.StatusOK, &requests)
+	defer server.Close()
+
+	opts := testTransportOptions()
+	opts.RequestsPerSecond = 50
+	opts.Burst = 2
+	client := &http.Client{Transport: NewTransport(nil, opts)}
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		resp, err := get(context.Background(), client, server.URL)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	// Two requests fit in the burst, the other three have to wait 20ms each
+	assert.True(t, time.Since(start) >= 55*time.Millisecond, "Requests should be rate limited")
+	assert.Equal(t, int32(5), requests)
+}
@@ -0,0 +1,33 @@
+package booking
+
+import (
+	"context"
+	"time"
+)
+
+// Timeline describes when a room is free during an interval which is
+// divided into consecutive slots of the same length.
+type Timeline struct {
+	Room  Room
+	Start time.Time
+	Step  time.Duration
+	Free  []bool
+}
+
+// TimelineService is implemented by booking services which can describe
+// the availability of their rooms over a longer interval at once.
+type TimelineService interface {
+	Timeline(ctx context.Context, start time.Time, end time.Time, step time.Duration) ([]Timeline, error)
+}
+
+// Slots returns the start of every slot of length step between start and end
+func Slots(start time.Time, end time.Time, step time.Duration) []time.Time {
+	var slots []time.Time
+	if step <= 0 {
+		return slots
+	}
+	for t := start; !t.Add(step).After(end); t = t.Add(step) {
+		slots = append(slots, t)
+	}
+	return slots
+}
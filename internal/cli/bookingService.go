@@ -1,39 +1,215 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
-
-	"sidus.io/boogrocha/internal/booking/timeedit"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 
 	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/cache"
 	"sidus.io/boogrocha/internal/booking/directory"
+	"sidus.io/boogrocha/internal/booking/dryrun"
+	"sidus.io/boogrocha/internal/booking/local"
+	"sidus.io/boogrocha/internal/booking/timeedit"
+	"sidus.io/boogrocha/internal/cli/commands"
 	logfmt "sidus.io/boogrocha/internal/log/fmt"
 )
 
-func getBookingService() booking.BookingService {
+func getBookingService(ctx context.Context) booking.BookingService {
+	var bs booking.BookingService
+	switch provider := viper.GetString("provider"); provider {
+	case "chalmers":
+		bs = getChalmersBookingService(ctx)
+	case "local":
+		bs = getLocalBookingService(ctx)
+	default:
+		fmt.Printf("Unknown provider '%s', use either 'chalmers' or 'local'\n", provider)
+		os.Exit(1)
+	}
+
+	if dryRun {
+		bs = dryrun.NewBookingService(bs, os.Stdout)
+	}
+	return bs
+}
+
+// getChalmersBookingService returns the booking systems of Chalmers
+func getChalmersBookingService(ctx context.Context) booking.BookingService {
 	if viper.GetString("chalmers.cid") == "" {
 		fmt.Println("No cid specified, set it permanently with 'bgc config set cid' or use the '--cid' flag")
 		os.Exit(1)
 	}
-	chalmersBS, err := timeedit.NewBookingService(viper.GetString("chalmers.cid"), getPassword(), timeedit.VersionChalmers)
+
+	// Only ask for the password once, and only if a provider has to log in
+	var once sync.Once
+	var password string
+	getPass := func() string {
+		once.Do(func() {
+			password = getPassword()
+		})
+		return password
+	}
+	// The limit is per host, so the providers can share the transport
+	transport := timeedit.NewTransport(nil, timeedit.TransportOptions{
+		MaxRetries:        viper.GetInt("timeedit.retries"),
+		MinBackoff:        viper.GetDuration("timeedit.min_backoff"),
+		MaxBackoff:        viper.GetDuration("timeedit.max_backoff"),
+		RequestsPerSecond: viper.GetFloat64("timeedit.requests_per_second"),
+		Burst:             viper.GetInt("timeedit.burst"),
+	})
+	opts := timeedit.Options{
+		Cid:       viper.GetString("chalmers.cid"),
+		Password:  getPass,
+		Sessions:  getSessionStore(),
+		Catalog:   getCatalog(),
+		Transport: transport,
+	}
+
+	instances, err := getInstances()
 	if err != nil {
-		fmt.Printf("Error: %s\n", err.Error())
+		fmt.Printf("Invalid TimeEdit instances in the config: %v\n", err)
 		os.Exit(1)
 	}
 
-	chalmersCovidBS, err := timeedit.NewBookingService(viper.GetString("chalmers.cid"), getPassword(), timeedit.VersionChalmersCovid)
+	providers := make(map[string]booking.BookingService)
+	// Unless configured otherwise rooms are booked through the providers
+	// in the order they are listed
+	var order []string
+	for _, instance := range instances {
+		timeEditBS, err := timeedit.NewBookingService(ctx, instance, opts)
+		if err != nil {
+			commands.Fail(fmt.Sprintf("Couldn't connect to TimeEdit (%s)", instance), err)
+		}
+		providers[timeEditBS.Provider()] = timeEditBS
+		order = append(order, timeEditBS.Provider())
+	}
+
+	directoryOpts, err := getDirectoryOptions(providers, order)
 	if err != nil {
-		fmt.Printf("Error: %s\n", err.Error())
+		fmt.Printf("Invalid provider timeouts in the config: %v\n", err)
 		os.Exit(1)
 	}
+	bs := directory.NewBookingServiceWithOptions(providers, &logfmt.Logger{}, directoryOpts)
 
-	bs := directory.NewBookingService(map[string]booking.BookingService{
-		chalmersBS.Provider():     chalmersBS,
-		chalmersCovidBS.Provider(): chalmersCovidBS,
-	}, &logfmt.Logger{})
+	return withCache(bs, viper.GetString("chalmers.cid"))
+}
 
-	return bs
+// withCache caches the results of bs for a short while, on disk unless
+// configured otherwise so that commands run right after each other can use
+// them. The namespace keeps the results of different users apart.
+func withCache(bs booking.BookingService, namespace string) booking.BookingService {
+	var store cache.Store = cache.NewMemoryStore()
+	if viper.GetBool("cache.disk") {
+		path, err := configPath()
+		if err == nil {
+			store, err = cache.NewFileStore(filepath.Join(path, "cache"))
+		}
+		if err != nil {
+			fmt.Printf("Failed to create cache, results are only cached in memory: %v\n", err)
+			store = cache.NewMemoryStore()
+		}
+	}
+	return cache.NewBookingService(bs, cache.Options{
+		Store:        store,
+		Namespace:    namespace,
+		AvailableTTL: viper.GetDuration("cache.available_ttl"),
+		BookingsTTL:  viper.GetDuration("cache.bookings_ttl"),
+	})
+}
+
+// getDirectoryOptions returns how long the providers are waited for and
+// which providers rooms found at several of them are booked through
+func getDirectoryOptions(providers map[string]booking.BookingService, order []string) (directory.Options, error) {
+	opts := directory.Options{
+		Timeout:        viper.GetDuration("providers.timeout"),
+		Timeouts:       make(map[string]time.Duration),
+		Primary:        viper.GetStringSlice("providers.primary"),
+		Grace:          viper.GetDuration("providers.grace"),
+		MergeRooms:     true,
+		Preferred:      order,
+		RefuseOverlaps: true,
+	}
+	if viper.IsSet("providers.preferred") {
+		opts.Preferred = viper.GetStringSlice("providers.preferred")
+	}
+	for provider, timeout := range viper.GetStringMapString("providers.timeouts") {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return opts, fmt.Errorf("%s: %w", provider, err)
+		}
+		// The keys of the config are lower case, unlike the providers
+		for name := range providers {
+			if strings.EqualFold(name, provider) {
+				opts.Timeouts[name] = d
+			}
+		}
+	}
+	return opts, nil
+}
+
+// getLocalBookingService returns a service booking the rooms of a local
+// file, which doesn't need any credentials
+func getLocalBookingService(ctx context.Context) booking.BookingService {
+	path := viper.GetString("local.path")
+	if path == "" {
+		configPath, err := configPath()
+		if err != nil {
+			commands.Fail("Couldn't find the local bookings", err)
+		}
+		path = filepath.Join(configPath, "local.json")
+	}
+
+	// New files get the rooms of the catalog so that they look familiar
+	var rooms []booking.Room
+	if c, err := getCatalog().Get(ctx); err == nil {
+		for _, name := range c.Names() {
+			rooms = append(rooms, booking.Room{Id: name, Seats: c[name].Seats, Campus: c[name].Campus})
+		}
+	}
+
+	localBS, err := local.NewBookingService(path, local.Options{Rooms: rooms})
+	if err != nil {
+		commands.Fail("Couldn't open the local bookings", err)
+	}
+
+	return directory.NewBookingServiceWithOptions(map[string]booking.BookingService{
+		localBS.Provider(): localBS,
+	}, &logfmt.Logger{}, directory.Options{RefuseOverlaps: true})
+}
+
+// getInstances returns the TimeEdit instances from the config, or the
+// Chalmers instances if none are configured
+func getInstances() ([]timeedit.Instance, error) {
+	if !viper.IsSet("timeedit.instances") {
+		return timeedit.DefaultInstances(), nil
+	}
+	var instances []timeedit.Instance
+	err := viper.UnmarshalKey("timeedit.instances", &instances)
+	return instances, err
+}
+
+// getSessionStore returns the store for TimeEdit sessions, or nil if
+// sessions shouldn't be reused between runs.
+func getSessionStore() timeedit.SessionStore {
+	lifetime := viper.GetDuration("timeedit.session_lifetime")
+	if lifetime <= 0 {
+		return nil
+	}
+	path, err := configPath()
+	if err != nil {
+		fmt.Printf("Failed to find sessions: %v\n", err)
+		return nil
+	}
+	store, err := timeedit.NewFileSessionStore(filepath.Join(path, "sessions"), lifetime)
+	if err != nil {
+		fmt.Printf("Failed to create session store: %v\n", err)
+		return nil
+	}
+	return store
 }
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+
+	"sidus.io/boogrocha/internal/booking/catalog"
+)
+
+var roomCatalog *catalog.Service
+
+// getCatalog returns the room catalog, kept next to the config
+func getCatalog() *catalog.Service {
+	if roomCatalog != nil {
+		return roomCatalog
+	}
+	path, err := configPath()
+	if err != nil {
+		fmt.Printf("Failed to find room catalog: %v\n", err)
+		path = ""
+	}
+	roomCatalog = catalog.NewService(
+		viper.GetString("catalog.url"),
+		path,
+		viper.GetDuration("catalog.max_age"),
+	)
+	return roomCatalog
+}
@@ -14,10 +14,14 @@ const Version = "0.1"
 
 func init() {
 
-	BgcCmd.AddCommand(commands.BookCmd(getBookingService, getRankingService))
+	BgcCmd.AddCommand(commands.BookCmd(getInterruptibleContext, withTimeout, getBookingService, getRankingService))
 	BgcCmd.AddCommand(commands.ConfigCmd(getSavePassword))
-	BgcCmd.AddCommand(commands.DeleteCmd(getBookingService))
-	BgcCmd.AddCommand(commands.ListCmd(getBookingService))
+	BgcCmd.AddCommand(commands.FindCmd(getContext, getBookingService, getRankingService))
+	BgcCmd.AddCommand(commands.FreeCmd(getContext, getBookingService, getRankingService))
+	BgcCmd.AddCommand(commands.DeleteCmd(getContext, getBookingService))
+	BgcCmd.AddCommand(commands.ListCmd(getContext, getBookingService))
+	BgcCmd.AddCommand(commands.RoomsCmd(getContext, getCatalog, getRankingService))
+	BgcCmd.AddCommand(commands.RankingsCmd(getRankingService))
 	BgcCmd.AddCommand(commands.VersionCmd(ApplicationName, Version))
 
 	loadFlags()
@@ -40,5 +44,6 @@ var BgcCmd = &cobra.Command{
 	Short: "Manage your group room bookings at Chalmers",
 	Long: `A lightweight, easy to use application for managing your
 		   group room bookings at Chalmers University of Technology`,
-	Run: nil,
+	PersistentPreRun: setTimeZone,
+	Run:              nil,
 }
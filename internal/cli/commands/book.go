@@ -2,6 +2,7 @@ package commands
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -11,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/dryrun"
 	"sidus.io/boogrocha/internal/filter"
 	"sidus.io/boogrocha/internal/ranking"
 )
@@ -27,7 +29,20 @@ const RoomFlagDefaultValue = ""
 const MessageFlagName = "message"
 const MessageFlagDefaultValue = ""
 
-func BookCmd(getBS func() booking.BookingService, getRS func() ranking.RankingService) *cobra.Command {
+const UntilFlagName = "until"
+const UntilFlagDefaultValue = ""
+
+const AllowOverlapFlagName = "allow-overlap"
+const AllowOverlapFlagDefaultValue = false
+
+const IgnoreListsFlagName = "ignore-lists"
+const IgnoreListsFlagDefaultValue = false
+
+// BookCmd books rooms in the contexts from getCtx bounded by withTimeout. A
+// recurring booking bounds every occurrence on its own, so that booking many
+// of them doesn't run out of time.
+func BookCmd(getCtx func() (context.Context, context.CancelFunc), withTimeout func(context.Context) (context.Context, context.CancelFunc),
+	getBS func(context.Context) booking.BookingService, getRS func() ranking.RankingService) *cobra.Command {
 	bookCmd := &cobra.Command{
 		Use:   "book {day} {time}",
 		Short: "Create a booking",
@@ -39,51 +54,80 @@ func BookCmd(getBS func() booking.BookingService, getRS func() ranking.RankingSe
 	roomSize := bookCmd.Flags().IntP(SizeFlagName, "s", SizeFlagDefaultValue, "Show only rooms where a specified number of people fit")
 	roomName := bookCmd.Flags().StringP(RoomFlagName, "r", RoomFlagDefaultValue, "Book specified room")
 	message := bookCmd.Flags().StringP(MessageFlagName, "m", MessageFlagDefaultValue, "Use specified message when booking")
+	until := bookCmd.Flags().StringP(UntilFlagName, "u", UntilFlagDefaultValue, "Repeat the booking every week until the specified date")
+	allowOverlap := bookCmd.Flags().Bool(AllowOverlapFlagName, AllowOverlapFlagDefaultValue, "Book even if you already have a booking at the same time")
+	ignoreLists := bookCmd.Flags().Bool(IgnoreListsFlagName, IgnoreListsFlagDefaultValue, "Ignore your favorite and blocked rooms")
 
+	getCtx = withAllowOverlap(getCtx, allowOverlap)
 	bookCmd.Run = func(cmd *cobra.Command, args []string) {
-		run(cmd, args, getBS, getRS, *campus, *roomSize, *roomName, *message)
+		if cmd.Flags().Changed(UntilFlagName) {
+			runRecurring(cmd, args, getCtx, withTimeout, getBS, getRS, *campus, *roomSize, *roomName, *message, *until, *ignoreLists)
+			return
+		}
+		run(cmd, args, bounded(getCtx, withTimeout), getBS, getRS, *campus, *roomSize, *roomName, *message, *ignoreLists)
 	}
 
 	return bookCmd
 }
 
-func run(cmd *cobra.Command, args []string, getBS func() booking.BookingService, getRS func() ranking.RankingService,
-	campus string, roomSize int, roomName string, message string) {
-	bs := getBS()
+// withAllowOverlap lets the bookings made with the contexts from getCtx
+// overlap with existing bookings if allow is set when the context is created
+func withAllowOverlap(getCtx func() (context.Context, context.CancelFunc), allow *bool) func() (context.Context, context.CancelFunc) {
+	return func() (context.Context, context.CancelFunc) {
+		ctx, cancel := getCtx()
+		if *allow {
+			ctx = booking.AllowOverlap(ctx)
+		}
+		return ctx, cancel
+	}
+}
+
+// bounded bounds the contexts from getCtx by withTimeout
+func bounded(getCtx func() (context.Context, context.CancelFunc), withTimeout func(context.Context) (context.Context, context.CancelFunc)) func() (context.Context, context.CancelFunc) {
+	return func() (context.Context, context.CancelFunc) {
+		ctx, cancel := getCtx()
+		ctx, cancelTimeout := withTimeout(ctx)
+		return ctx, func() {
+			cancelTimeout()
+			cancel()
+		}
+	}
+}
+
+func run(cmd *cobra.Command, args []string, getCtx func() (context.Context, context.CancelFunc),
+	getBS func(context.Context) booking.BookingService, getRS func() ranking.RankingService,
+	campus string, roomSize int, roomName string, message string, ignoreLists bool) {
+	ctx, cancel := getCtx()
+	defer cancel()
+
+	bs := getBS(ctx)
 
 	startDate, endDate := readArgs(args)
 
 	if startDate.Before(time.Now()) {
-		fmt.Printf("booking has to be in the future")
+		fmt.Printf("booking has to be in the future\n")
 		return
 	}
 
-	available, err := bs.Available(startDate, endDate)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	available, err := bs.Available(ctx, startDate, endDate)
+	if err = warnPartial(err); err != nil {
+		Fail("Couldn't get available rooms", err)
 	}
 
 	rs := getRS()
 	rankings, err := rs.GetRankings()
 	if err != nil {
 		fmt.Printf("Failed to get rankings: %v\n", err)
-	} else {
-		available = rankings.Sort(available)
+	}
+	prefs := preferences(rankings, ignoreLists)
+	if prefs != nil {
+		available = prefs.Sort(available, startDate)
 	}
 
 	var n int
 
 	if !cmd.Flags().Changed(RoomFlagName) {
-		var filters []filter.RoomFilter
-		if cmd.Flags().Changed(CampusFlagName) {
-			filters = append(filters, getCampusFilter(campus))
-		}
-		if cmd.Flags().Changed(SizeFlagName) {
-			filters = append(filters, getSizeFilter(roomSize))
-		}
-
-		available = filter.Filter(available, filters)
+		available = prefs.Filter(filter.Filter(available, getFilters(cmd, campus, roomSize)))
 
 		showAvailable(available, cmd.Flags().Changed(SizeFlagName))
 
@@ -143,15 +187,19 @@ func run(cmd *cobra.Command, args []string, getBS func() booking.BookingService,
 		b.Text = message
 	}
 
-	err = bs.Book(b)
+	created, err := bs.Book(ctx, b)
 	if err != nil {
-		fmt.Println("couldn't book room")
-		os.Exit(1)
+		Fail("Couldn't book room", err)
+	}
+	if isDryRun(bs) {
+		fmt.Printf("Nothing was booked since it's a dry run\n")
+	} else {
+		fmt.Printf("Booked %s successfully! (id %s)\n", created.Room.Id, created.Id)
+		fmt.Printf("Undo with 'bgc delete id %s'\n", created.Id)
 	}
-	fmt.Printf("Booked %s successfully!\n", available[n].Id)
 
 	if rankings != nil {
-		rankings.Update(available[n], available)
+		rankings.Update(b.Room, available, startDate)
 		err := rs.SaveRankings(rankings)
 		if err != nil {
 			fmt.Printf("Could not save updated rankings: %v\n", err)
@@ -160,6 +208,17 @@ func run(cmd *cobra.Command, args []string, getBS func() booking.BookingService,
 
 }
 
+func getFilters(cmd *cobra.Command, campus string, roomSize int) []filter.RoomFilter {
+	var filters []filter.RoomFilter
+	if cmd.Flags().Changed(CampusFlagName) {
+		filters = append(filters, getCampusFilter(campus))
+	}
+	if cmd.Flags().Changed(SizeFlagName) {
+		filters = append(filters, getSizeFilter(roomSize))
+	}
+	return filters
+}
+
 func getCampusFilter(campus string) filter.RoomFilter {
 	return func(r booking.Room) bool {
 		if len(r.Campus) == 0 {
@@ -178,6 +237,22 @@ func getSizeFilter(size int) filter.RoomFilter {
 	}
 }
 
+// isDryRun reports whether bs only describes the bookings it would make
+func isDryRun(bs booking.BookingService) bool {
+	_, ok := bs.(*dryrun.BookingService)
+	return ok
+}
+
+// preferences returns the rankings that rooms are ordered and hidden by,
+// which are the rankings without the favorite and blocked rooms if
+// ignoreLists is set
+func preferences(rankings *ranking.Rankings, ignoreLists bool) *ranking.Rankings {
+	if rankings == nil || !ignoreLists {
+		return rankings
+	}
+	return rankings.WithoutLists()
+}
+
 func prompt(message string) (string, error) {
 	fmt.Printf("==> %s\n", message)
 	fmt.Print("==> ")
@@ -203,23 +278,23 @@ func readArgs(args []string) (time.Time, time.Time) {
 		os.Exit(1)
 	}
 
-	return date.Add(start), date.Add(end)
+	return atTime(date, start), atTime(date, end)
+}
+
+// atTime returns the time of day d on the date, in the time zone of the date.
+// Unlike date.Add(d) it keeps the wall clock time on days when daylight
+// saving time starts or ends.
+func atTime(date time.Time, d time.Duration) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), int(d/time.Hour), int(d%time.Hour/time.Minute), 0, 0, date.Location())
 }
 
 func showAvailable(available []booking.Room, showRoomSize bool) {
 	for i := len(available) - 1; i >= 0; i-- {
 		room := available[i]
 
-		prevIsSame := i > 0 && available[i-1].Id == room.Id
-		nextIsSame := i < len(available)-1 && available[i+1].Id == room.Id
-		roomName := room.Id
-		if prevIsSame || nextIsSame {
-			roomName = fmt.Sprintf("%s.%s", room.Provider, room.Id)
-		}
-
 		roomString := fmt.Sprintf("%4s %-13s",
 			fmt.Sprintf("[%d]", i+1),
-			roomName)
+			room.Id)
 		if showRoomSize {
 			roomString = fmt.Sprintf("%s (%d)",
 				roomString, room.Seats)
@@ -276,11 +351,11 @@ func extractTime(s string) (time.Duration, error) {
 }
 
 func extractDate(s string) (time.Time, error) {
-	switch n := time.Now(); strings.ToLower(s) {
+	switch n := now(); strings.ToLower(s) {
 	case "today":
 		return time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, n.Location()), nil
 	case "tomorrow":
-		return time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, n.Location()).Add(time.Hour * 24), nil
+		return time.Date(n.Year(), n.Month(), n.Day()+1, 0, 0, 0, 0, n.Location()), nil
 	default:
 		t, err := extractDateAbsolute(s, n)
 		if err != nil {
@@ -294,8 +369,7 @@ func extractDateAbsolute(s string, n time.Time) (time.Time, error) {
 	weekday, err := parseWeekday(strings.ToLower(s))
 	if err == nil {
 		diff := daysToAdd(n.Weekday(), weekday)
-		t := time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, n.Location())
-		return t.Add(time.Hour * 24 * time.Duration(diff)), nil
+		return time.Date(n.Year(), n.Month(), n.Day()+diff, 0, 0, 0, 0, n.Location()), nil
 	}
 
 	switch len(s) {
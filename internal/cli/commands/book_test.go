@@ -5,6 +5,9 @@ import (
 	"time"
 
 	"github.com/magiconair/properties/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/ranking"
 )
 
 func TestDaysToAdd(t *testing.T) {
@@ -14,3 +17,46 @@ func TestDaysToAdd(t *testing.T) {
 	assert.Equal(t, daysToAdd(time.Monday, time.Tuesday), 1)
 	assert.Equal(t, daysToAdd(time.Tuesday, time.Monday), 6)
 }
+
+func TestPreferences(t *testing.T) {
+	basement := booking.Room{Provider: "A", Id: "EG-B1"}
+	other := booking.Room{Provider: "A", Id: "EG-2515"}
+	rankings := &ranking.Rankings{Blocked: []ranking.Pattern{"EG-B*"}}
+
+	assert.Equal(t, preferences(rankings, false).Filter([]booking.Room{basement, other}), []booking.Room{other})
+	assert.Equal(t, preferences(rankings, true).Filter([]booking.Room{basement, other}), []booking.Room{basement, other})
+	assert.Equal(t, preferences(nil, true).Filter([]booking.Room{basement, other}), []booking.Room{basement, other})
+}
+
+func TestReadArgs_DaylightSaving(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skip("the Europe/Stockholm time zone isn't available")
+	}
+	SetTimeZone(stockholm)
+	defer SetTimeZone(time.Local)
+
+	// Daylight saving time starts at 02:00 on 2020-03-29 and ends at 03:00
+	// on 2020-10-25, the times should still be the ones given
+	for _, date := range []string{"20200329", "20201025"} {
+		start, end := readArgs([]string{date, "13-15"})
+		assert.Equal(t, start.Format("20060102 15:04"), date+" 13:00")
+		assert.Equal(t, end.Format("20060102 15:04"), date+" 15:00")
+		assert.Equal(t, end.Sub(start), 2*time.Hour)
+		assert.Equal(t, start.Location(), stockholm)
+	}
+}
+
+func TestExtractDateAbsolute_DaylightSaving(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skip("the Europe/Stockholm time zone isn't available")
+	}
+
+	// The Monday after daylight saving time starts should begin at midnight
+	saturday := time.Date(2020, 3, 28, 18, 0, 0, 0, stockholm)
+	monday, err := extractDateAbsolute("monday", saturday)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, monday, time.Date(2020, 3, 30, 0, 0, 0, 0, stockholm))
+	assert.Equal(t, atTime(monday, 8*time.Hour+30*time.Minute), time.Date(2020, 3, 30, 8, 30, 0, 0, stockholm))
+}
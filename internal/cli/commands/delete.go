@@ -2,6 +2,7 @@ package commands
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -14,27 +15,36 @@ import (
 
 var deleteAll bool
 
-func DeleteCmd(getBS func() booking.BookingService) *cobra.Command {
+func DeleteCmd(getCtx func() (context.Context, context.CancelFunc), getBS func(context.Context) booking.BookingService) *cobra.Command {
 	DeleteCmd := &cobra.Command{
 		Use:   "delete",
 		Short: "Delete a booking",
 		Long:  `Used to delete a booking`,
 		Run: func(cmd *cobra.Command, args []string) {
-			runDelete(cmd, args, getBS)
+			runDelete(cmd, args, getCtx, getBS)
 		},
 	}
 	DeleteCmd.Flags().BoolVarP(&deleteAll, "all", "", false, "Unbooks all current bookings")
-	DeleteCmd.AddCommand(deleteIdCmd)
+	DeleteCmd.AddCommand(deleteIdCmd(getCtx, getBS))
 	return DeleteCmd
 }
 
-func runDelete(cmd *cobra.Command, args []string, getBS func() booking.BookingService) {
-	bs := getBS()
-	bookings, err := bs.MyBookings()
-	if err != nil {
-		fmt.Printf("Failed to get bookings: %v \n", err)
-		os.Exit(1)
+func runDelete(cmd *cobra.Command, args []string, getCtx func() (context.Context, context.CancelFunc),
+	getBS func(context.Context) booking.BookingService) {
+	ctx, cancel := getCtx()
+	defer cancel()
+
+	bs := getBS(ctx)
+	if deleteAll {
+		deleteAllBookings(ctx, bs)
+		return
+	}
+
+	bookings, err := bs.MyBookings(ctx)
+	if err = warnPartial(err); err != nil {
+		Fail("Failed to get bookings", err)
 	}
+	bookings = inTimeZone(bookings, location)
 
 	fmt.Printf("    %-7s %-13s %-15s %s\n", "DATE", "TIME", "ROOM", "TEXT")
 	for i, booking := range bookings {
@@ -70,10 +80,9 @@ func runDelete(cmd *cobra.Command, args []string, getBS func() booking.BookingSe
 
 	if (n) < len(bookings) && (n) >= 0 {
 		fmt.Printf("Deleting booking %d...\n", n+1)
-		err := bs.UnBook(bookings[n])
+		err := bs.UnBook(ctx, bookings[n])
 		if err != nil {
-			fmt.Println("couldn't delete booking")
-			os.Exit(1)
+			Fail("Couldn't delete booking", err)
 		}
 		fmt.Println("Booking deleted successfully!")
 
@@ -83,9 +92,87 @@ func runDelete(cmd *cobra.Command, args []string, getBS func() booking.BookingSe
 
 }
 
-var deleteIdCmd = &cobra.Command{
-	Use:   "delete id {id}",
-	Short: "Delete ",
-	Long:  "",
-	Run:   nil,
+func deleteAllBookings(ctx context.Context, bs booking.BookingService) {
+	// The texts aren't shown so there's no need to fetch them
+	bookings, err := bs.MyBookings(booking.WithoutText(ctx))
+	if err = warnPartial(err); err != nil {
+		Fail("Failed to get bookings", err)
+	}
+	bookings = inTimeZone(bookings, location)
+	if len(bookings) == 0 {
+		fmt.Println("No bookings to delete")
+		return
+	}
+
+	var lastErr error
+	for _, b := range bookings {
+		fmt.Printf("Deleting %s %s %s...\n", b.Start.Format("02/01"), formatTime(b), b.Room.Id)
+		err := bs.UnBook(ctx, b)
+		if err != nil {
+			fmt.Printf("Couldn't delete booking: %v\n", err)
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		Fail("Couldn't delete all bookings", lastErr)
+	}
+	fmt.Println("All bookings deleted successfully!")
+}
+
+func deleteIdCmd(getCtx func() (context.Context, context.CancelFunc), getBS func(context.Context) booking.BookingService) *cobra.Command {
+	return &cobra.Command{
+		Use:   "id {id}",
+		Short: "Delete a booking by its id",
+		Long:  "Delete a booking by the id shown when booking or by 'bgc list --json'",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runDeleteId(args[0], getCtx, getBS)
+		},
+	}
+}
+
+func runDeleteId(id string, getCtx func() (context.Context, context.CancelFunc), getBS func(context.Context) booking.BookingService) {
+	ctx, cancel := getCtx()
+	defer cancel()
+
+	bs := getBS(ctx)
+	bookings, err := bs.MyBookings(booking.WithoutText(ctx))
+	if err = warnPartial(err); err != nil {
+		Fail("Failed to get bookings", err)
+	}
+	bookings = inTimeZone(bookings, location)
+
+	b, err := findBooking(bookings, id)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Deleting %s %s %s...\n", b.Start.Format("02/01"), formatTime(b), b.Room.Id)
+	err = bs.UnBook(ctx, b)
+	if err != nil {
+		Fail("Couldn't delete booking", err)
+	}
+	fmt.Println("Booking deleted successfully!")
+}
+
+// findBooking returns the booking with the given id. Since the ids are only
+// unique within a provider the id may be prefixed with the provider, as in
+// TimeEditchalmers/123.
+func findBooking(bookings []booking.Booking, id string) (booking.Booking, error) {
+	var found []booking.Booking
+	for _, b := range bookings {
+		if b.Id == id || b.Room.Provider+"/"+b.Id == id {
+			found = append(found, b)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return booking.Booking{}, fmt.Errorf("no booking with id %s", id)
+	case 1:
+		return found[0], nil
+	default:
+		return booking.Booking{}, fmt.Errorf("several bookings have id %s, prefix it with the provider like %s/%s",
+			id, found[0].Room.Provider, id)
+	}
 }
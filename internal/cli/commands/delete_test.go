@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+func TestFindBooking(t *testing.T) {
+	bookings := []booking.Booking{
+		{Id: "1", Room: booking.Room{Provider: "TimeEditchalmers", Id: "EG-2515"}},
+		{Id: "2", Room: booking.Room{Provider: "TimeEditchalmers", Id: "SB-G311"}},
+		{Id: "2", Room: booking.Room{Provider: "TimeEditchalmers_covid", Id: "SB-G312"}},
+	}
+
+	b, err := findBooking(bookings, "1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, b.Room.Id, "EG-2515")
+
+	_, err = findBooking(bookings, "3")
+	assert.Equal(t, err != nil, true)
+
+	_, err = findBooking(bookings, "2")
+	assert.Equal(t, err != nil, true, "Ids shared by several providers should be ambiguous")
+
+	b, err = findBooking(bookings, "TimeEditchalmers_covid/2")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, b.Room.Id, "SB-G312")
+}
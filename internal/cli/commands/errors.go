@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/directory"
+)
+
+// Exit codes used when a command fails
+const (
+	ExitFailure              = 1
+	ExitRoomUnavailable      = 3
+	ExitAuthenticationFail   = 4
+	ExitQuotaExceeded        = 5
+	ExitOutsideBookingWindow = 6
+	ExitProviderUnreachable  = 7
+	ExitParseFailure         = 8
+	ExitTimeout              = 9
+	ExitUnconfirmed          = 10
+	ExitOverlappingBooking   = 11
+	ExitInterrupted          = 130
+)
+
+var errorHints = []struct {
+	err  error
+	code int
+	hint string
+}{
+	{context.Canceled, ExitInterrupted, "The command was interrupted"},
+	{context.DeadlineExceeded, ExitTimeout, "The command timed out, try again or increase the timeout with '--timeout'"},
+	{booking.ErrRoomUnavailable, ExitRoomUnavailable, "The room is already booked at that time, pick another room or time"},
+	{booking.ErrNoSuchRoom, ExitRoomUnavailable, "The room doesn't exist, check the spelling of the room name"},
+	{booking.ErrAuthenticationFailed, ExitAuthenticationFail, "Login failed, check your credentials with 'bgc config set cid' and 'bgc config set pass'"},
+	{booking.ErrQuotaExceeded, ExitQuotaExceeded, "You have reached the maximum number of bookings, remove one with 'bgc delete' first"},
+	{booking.ErrOutsideBookingWindow, ExitOutsideBookingWindow, "The time is outside of the period rooms can be booked in, try a date closer to today"},
+	{booking.ErrProviderUnreachable, ExitProviderUnreachable, "Couldn't reach the booking system, check your connection and try again"},
+	{booking.ErrUnconfirmed, ExitUnconfirmed, "The booking system accepted the booking but it couldn't be found afterwards, check 'bgc list'"},
+	{booking.ErrOverlappingBooking, ExitOverlappingBooking, "You already have a booking at that time, use '--allow-overlap' to book anyway"},
+	{booking.ErrParseFailure, ExitParseFailure, "Couldn't understand the response from the booking system, bgc might have to be updated"},
+}
+
+// describeError returns an actionable explanation of err and the exit code
+// a command failing because of it should use.
+func describeError(err error) (string, int) {
+	for _, h := range errorHints {
+		if errors.Is(err, h.err) {
+			return h.hint, h.code
+		}
+	}
+	return "", ExitFailure
+}
+
+// Fail prints msg together with an explanation of err and exits with the
+// exit code matching err.
+func Fail(msg string, err error) {
+	fmt.Printf("%s: %v\n", msg, err)
+	hint, code := describeError(err)
+	if hint != "" {
+		fmt.Println(hint)
+	}
+	os.Exit(code)
+}
+
+// warnPartial warns about the providers missing from a partial result and
+// returns nil for it, so that the results that were found can still be
+// used. Any other error is returned as it is.
+func warnPartial(err error) error {
+	var partial *directory.PartialError
+	if !errors.As(err, &partial) {
+		return err
+	}
+	errs := partial.Errors()
+	for _, provider := range partial.Missing() {
+		fmt.Printf("Warning: results from %s missing (%v)\n", provider, errs[provider])
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+func TestDescribeError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{booking.ErrRoomUnavailable, ExitRoomUnavailable},
+		{fmt.Errorf("provider A: %w", booking.ErrAuthenticationFailed), ExitAuthenticationFail},
+		{booking.Wrap(booking.ErrProviderUnreachable, context.DeadlineExceeded), ExitTimeout},
+		{booking.Wrap(booking.ErrProviderUnreachable, fmt.Errorf("connection reset")), ExitProviderUnreachable},
+		{booking.Wrap(booking.ErrOverlappingBooking, fmt.Errorf("EG-2515")), ExitOverlappingBooking},
+		{fmt.Errorf("something else"), ExitFailure},
+	}
+	for _, tt := range tests {
+		_, code := describeError(tt.err)
+		assert.Equal(t, code, tt.code, tt.err.Error())
+	}
+}
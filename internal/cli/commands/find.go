@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/ranking"
+	"sidus.io/boogrocha/internal/slot"
+)
+
+const BetweenFlagName = "between"
+const BetweenFlagDefaultValue = "8-18"
+
+const LimitFlagName = "limit"
+const LimitFlagDefaultValue = 10
+
+const AllFlagName = "all"
+const AllFlagDefaultValue = false
+
+func FindCmd(getCtx func() (context.Context, context.CancelFunc), getBS func(context.Context) booking.BookingService,
+	getRS func() ranking.RankingService) *cobra.Command {
+	findCmd := &cobra.Command{
+		Use:   "find {day} {duration}",
+		Short: "Find free time slots",
+		Long: `Find intervals of the given duration (e.g. 2h, 90m or 1:30) during a day
+where a room is available, ordered by your room preferences`,
+		Args: cobra.ExactArgs(2),
+	}
+
+	campus := findCmd.Flags().StringP(CampusFlagName, "c", CampusFlagDefaultValue, "Show only rooms from either (J)ohanneberg or (L)indholmen")
+	roomSize := findCmd.Flags().IntP(SizeFlagName, "s", SizeFlagDefaultValue, "Show only rooms where a specified number of people fit")
+	between := findCmd.Flags().StringP(BetweenFlagName, "b", BetweenFlagDefaultValue, "Only search within this time interval of the day")
+	limit := findCmd.Flags().IntP(LimitFlagName, "l", LimitFlagDefaultValue, "Maximum number of suggestions to show")
+	all := findCmd.Flags().BoolP(AllFlagName, "a", AllFlagDefaultValue, "Show every free interval instead of only the earliest one per room")
+
+	findCmd.Run = func(cmd *cobra.Command, args []string) {
+		runFind(cmd, args, getCtx, getBS, getRS, *campus, *roomSize, *between, *limit, *all)
+	}
+
+	return findCmd
+}
+
+func runFind(cmd *cobra.Command, args []string, getCtx func() (context.Context, context.CancelFunc),
+	getBS func(context.Context) booking.BookingService, getRS func() ranking.RankingService,
+	campus string, roomSize int, between string, limit int, all bool) {
+	date, err := extractDate(args[0])
+	if err != nil {
+		fmt.Printf("couldn't interpret \"%s\" as a date\n", args[0])
+		os.Exit(1)
+	}
+	duration, err := extractDuration(args[1])
+	if err != nil {
+		fmt.Printf("couldn't interpret \"%s\" as a duration\n", args[1])
+		os.Exit(1)
+	}
+	from, to, err := extractTimes(between)
+	if err != nil {
+		fmt.Printf("couldn't interpret \"%s\" as a time interval\n", between)
+		os.Exit(1)
+	}
+
+	query := slot.Query{
+		From:     atTime(date, from),
+		To:       atTime(date, to),
+		Duration: duration,
+		Filters:  getFilters(cmd, campus, roomSize),
+	}
+	if now := time.Now(); query.From.Before(now) {
+		query.From = now
+	}
+
+	rankings, err := getRS().GetRankings()
+	if err != nil {
+		fmt.Printf("Failed to get rankings: %v\n", err)
+	}
+
+	ctx, cancel := getCtx()
+	defer cancel()
+
+	suggestions, err := slot.Search(ctx, getBS(ctx), query, rankings)
+	if err = warnPartial(err); err != nil {
+		Fail("Couldn't search for free rooms", err)
+	}
+
+	if !all {
+		suggestions = slot.FirstPerRoom(suggestions)
+	}
+	if len(suggestions) == 0 {
+		fmt.Println("No free rooms found")
+		return
+	}
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	fmt.Printf("%-9s %-11s %-15s %s\n", "DATE", "TIME", "ROOM", "SEATS")
+	for _, s := range suggestions {
+		b := booking.Booking{Start: s.Start, End: s.End}
+		fmt.Printf("%-9s %-11s %-15s %d\n",
+			formatDateWithWeekday(b),
+			formatTime(b),
+			s.Room.Id,
+			s.Room.Seats,
+		)
+	}
+}
+
+// extractDuration parses durations written either as a go duration (2h30m)
+// or as a time of day (2:30, 0230 or 2).
+func extractDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err == nil {
+		return d, nil
+	}
+	return extractTime(s)
+}
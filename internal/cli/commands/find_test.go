@@ -0,0 +1,23 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestExtractDuration(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"2h":    2 * time.Hour,
+		"90m":   90 * time.Minute,
+		"1h30m": 90 * time.Minute,
+		"1:30":  90 * time.Minute,
+		"0145":  105 * time.Minute,
+		"2":     2 * time.Hour,
+	} {
+		d, err := extractDuration(s)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, d, want, s)
+	}
+}
@@ -0,0 +1,252 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/directory"
+	"sidus.io/boogrocha/internal/filter"
+	"sidus.io/boogrocha/internal/ranking"
+)
+
+const StepFlagName = "step"
+const StepFlagDefaultValue = 30 * time.Minute
+
+const WeekFlagName = "week"
+const WeekFlagDefaultValue = false
+
+// Number of days shown with --week
+const weekDays = 7
+
+const freeCell = '.'
+const busyCell = '#'
+
+func FreeCmd(getCtx func() (context.Context, context.CancelFunc), getBS func(context.Context) booking.BookingService,
+	getRS func() ranking.RankingService) *cobra.Command {
+	freeCmd := &cobra.Command{
+		Use:   "free {day}",
+		Short: "Show when rooms are free during a day",
+		Long: `Show a grid with the rooms as rows and the time of the day as columns.
+With --week the columns are instead the days of the week starting at the day,
+showing which rooms are free during the whole time interval of each day.`,
+		Args: cobra.ExactArgs(1),
+	}
+
+	campus := freeCmd.Flags().StringP(CampusFlagName, "c", CampusFlagDefaultValue, "Show only rooms from either (J)ohanneberg or (L)indholmen")
+	roomSize := freeCmd.Flags().IntP(SizeFlagName, "s", SizeFlagDefaultValue, "Show only rooms where a specified number of people fit")
+	between := freeCmd.Flags().StringP(BetweenFlagName, "b", BetweenFlagDefaultValue, "Only show this time interval of the day")
+	step := freeCmd.Flags().DurationP(StepFlagName, "", StepFlagDefaultValue, "Length of each column, either 15m or 30m")
+	week := freeCmd.Flags().BoolP(WeekFlagName, "w", WeekFlagDefaultValue, "Show which rooms are free on each day of a week")
+
+	freeCmd.Run = func(cmd *cobra.Command, args []string) {
+		if *week {
+			runFreeWeek(cmd, args, getCtx, getBS, getRS, *campus, *roomSize, *between)
+			return
+		}
+		runFree(cmd, args, getCtx, getBS, getRS, *campus, *roomSize, *between, *step)
+	}
+
+	return freeCmd
+}
+
+func runFree(cmd *cobra.Command, args []string, getCtx func() (context.Context, context.CancelFunc),
+	getBS func(context.Context) booking.BookingService, getRS func() ranking.RankingService,
+	campus string, roomSize int, between string, step time.Duration) {
+	date, err := extractDate(args[0])
+	if err != nil {
+		fmt.Printf("couldn't interpret \"%s\" as a date\n", args[0])
+		os.Exit(1)
+	}
+	from, to, err := extractTimes(between)
+	if err != nil {
+		fmt.Printf("couldn't interpret \"%s\" as a time interval\n", between)
+		os.Exit(1)
+	}
+	if step != 15*time.Minute && step != 30*time.Minute {
+		fmt.Println("the step has to be either 15m or 30m")
+		os.Exit(1)
+	}
+
+	ctx, cancel := getCtx()
+	defer cancel()
+
+	timelines, err := directory.Timeline(ctx, getBS(ctx), atTime(date, from), atTime(date, to), step)
+	if err = warnPartial(err); err != nil {
+		Fail("Couldn't get available rooms", err)
+	}
+
+	rankings, err := getRS().GetRankings()
+	if err != nil {
+		fmt.Printf("Failed to get rankings: %v\n", err)
+	}
+
+	timelines = sortTimelines(timelines, getFilters(cmd, campus, roomSize), rankings, atTime(date, from))
+	if len(timelines) == 0 {
+		fmt.Println("No rooms found")
+		return
+	}
+
+	showTimelines(timelines)
+}
+
+func runFreeWeek(cmd *cobra.Command, args []string, getCtx func() (context.Context, context.CancelFunc),
+	getBS func(context.Context) booking.BookingService, getRS func() ranking.RankingService,
+	campus string, roomSize int, between string) {
+	date, err := extractDate(args[0])
+	if err != nil {
+		fmt.Printf("couldn't interpret \"%s\" as a date\n", args[0])
+		os.Exit(1)
+	}
+	from, to, err := extractTimes(between)
+	if err != nil {
+		fmt.Printf("couldn't interpret \"%s\" as a time interval\n", between)
+		os.Exit(1)
+	}
+
+	ctx, cancel := getCtx()
+	defer cancel()
+
+	days, err := directory.AvailableRange(ctx, getBS(ctx), atTime(date, from), atTime(date.AddDate(0, 0, weekDays-1), to))
+	if err = warnPartial(err); err != nil {
+		Fail("Couldn't get available rooms", err)
+	}
+
+	rankings, err := getRS().GetRankings()
+	if err != nil {
+		fmt.Printf("Failed to get rankings: %v\n", err)
+	}
+
+	var rooms []booking.Room
+	seen := make(map[booking.Room]bool)
+	for _, day := range days {
+		for _, room := range day.Rooms {
+			if !seen[room] {
+				seen[room] = true
+				rooms = append(rooms, room)
+			}
+		}
+	}
+
+	// A week spans every weekday so the rooms are ordered by their global ranks
+	rooms = sortRooms(rooms, getFilters(cmd, campus, roomSize), rankings, time.Time{})
+	if len(rooms) == 0 {
+		fmt.Println("No rooms found")
+		return
+	}
+
+	showWeek(days, rooms)
+}
+
+// sortRooms filters the rooms, leaving out the blocked ones, and orders them
+// by their rankings for bookings starting at start
+func sortRooms(rooms []booking.Room, filters []filter.RoomFilter, rankings *ranking.Rankings, start time.Time) []booking.Room {
+	rooms = rankings.Filter(filter.Filter(rooms, filters))
+	if rankings != nil {
+		rooms = rankings.Sort(rooms, start)
+	}
+	return rooms
+}
+
+// sortTimelines filters the timelines by their rooms and orders them by the
+// rankings of the rooms for bookings starting at start.
+func sortTimelines(timelines []booking.Timeline, filters []filter.RoomFilter, rankings *ranking.Rankings, start time.Time) []booking.Timeline {
+	byRoom := make(map[booking.Room]booking.Timeline)
+	var rooms []booking.Room
+	for _, t := range timelines {
+		byRoom[t.Room] = t
+		rooms = append(rooms, t.Room)
+	}
+
+	rooms = sortRooms(rooms, filters, rankings, start)
+
+	sorted := make([]booking.Timeline, 0, len(rooms))
+	for _, room := range rooms {
+		sorted = append(sorted, byRoom[room])
+	}
+	return sorted
+}
+
+func showTimelines(timelines []booking.Timeline) {
+	fmt.Printf("%-15s %s\n", "ROOM", timelineHeader(timelines[0]))
+	for _, t := range timelines {
+		fmt.Printf("%-15s %s\n", t.Room.Id, timelineCells(t))
+	}
+	fmt.Printf("\n'%c' free, '%c' booked\n", freeCell, busyCell)
+}
+
+func showWeek(days []booking.DayAvailability, rooms []booking.Room) {
+	fmt.Printf("%-15s %s\n", "ROOM", weekHeader(days))
+	for _, room := range rooms {
+		fmt.Printf("%-15s %s\n", room.Id, weekCells(days, room))
+	}
+	fmt.Printf("\n'%c' free, '%c' booked at some point between %s and %s\n",
+		freeCell, busyCell, days[0].Start.Format("15:04"), days[0].End.Format("15:04"))
+}
+
+// Number of characters used to show a day of a week
+const dayWidth = 10
+
+func weekHeader(days []booking.DayAvailability) string {
+	var header strings.Builder
+	for _, day := range days {
+		header.WriteString(fmt.Sprintf("%-*s", dayWidth, day.Start.Format("Mon 02/01")))
+	}
+	return strings.TrimRight(header.String(), " ")
+}
+
+func weekCells(days []booking.DayAvailability, room booking.Room) string {
+	var cells strings.Builder
+	for _, day := range days {
+		cell := busyCell
+		for _, r := range day.Rooms {
+			if r == room {
+				cell = freeCell
+				break
+			}
+		}
+		cells.WriteString(fmt.Sprintf("%-*c", dayWidth, cell))
+	}
+	return strings.TrimRight(cells.String(), " ")
+}
+
+// Number of characters used to show an hour of a timeline
+const hourWidth = 4
+
+// timelineHeader labels every full hour of the timeline
+func timelineHeader(t booking.Timeline) string {
+	width := cellWidth(t)
+	header := []rune(strings.Repeat(" ", len(t.Free)*width+1))
+	for i := range t.Free {
+		slot := t.Start.Add(time.Duration(i) * t.Step)
+		if slot.Minute() == 0 {
+			copy(header[i*width:], []rune(slot.Format("15")))
+		}
+	}
+	return strings.TrimRight(string(header), " ")
+}
+
+func timelineCells(t booking.Timeline) string {
+	cell := map[bool]string{
+		true:  strings.Repeat(string(freeCell), cellWidth(t)),
+		false: strings.Repeat(string(busyCell), cellWidth(t)),
+	}
+	var cells strings.Builder
+	for _, free := range t.Free {
+		cells.WriteString(cell[free])
+	}
+	return cells.String()
+}
+
+func cellWidth(t booking.Timeline) int {
+	width := int(hourWidth * t.Step / time.Hour)
+	if width < 1 {
+		return 1
+	}
+	return width
+}
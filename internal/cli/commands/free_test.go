@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/filter"
+	"sidus.io/boogrocha/internal/ranking"
+)
+
+func TestTimelineGrid(t *testing.T) {
+	timeline := booking.Timeline{
+		Start: time.Date(2019, 10, 15, 8, 0, 0, 0, time.UTC),
+		Step:  30 * time.Minute,
+		Free:  []bool{true, true, false, false, true, true},
+	}
+	assert.Equal(t, timelineHeader(timeline), "08  09  10")
+	assert.Equal(t, timelineCells(timeline), "....####....")
+
+	timeline.Start = time.Date(2019, 10, 15, 8, 30, 0, 0, time.UTC)
+	assert.Equal(t, timelineHeader(timeline), "  09  10  11")
+}
+
+func TestSortTimelines(t *testing.T) {
+	a := booking.Room{Provider: "A", Id: "a", Seats: 4}
+	b := booking.Room{Provider: "A", Id: "b", Seats: 8}
+	c := booking.Room{Provider: "A", Id: "c", Seats: 8}
+	blocked := booking.Room{Provider: "A", Id: "d", Seats: 8}
+
+	timelines := sortTimelines([]booking.Timeline{{Room: a}, {Room: b}, {Room: c}, {Room: blocked}},
+		[]filter.RoomFilter{getSizeFilter(6)}, &ranking.Rankings{Buckets: map[ranking.Bucket]ranking.Ranks{
+			ranking.Global: {b: {Penalty: 3}, c: {Penalty: 1}},
+		}, Blocked: []ranking.Pattern{"d"}}, time.Time{})
+
+	assert.Equal(t, len(timelines), 2)
+	assert.Equal(t, timelines[0].Room, c)
+	assert.Equal(t, timelines[1].Room, b)
+}
+
+func TestWeekGrid(t *testing.T) {
+	a := booking.Room{Provider: "A", Id: "a"}
+	b := booking.Room{Provider: "A", Id: "b"}
+	start := time.Date(2019, 10, 14, 13, 0, 0, 0, time.UTC)
+
+	days := []booking.DayAvailability{
+		{Start: start, End: start.Add(2 * time.Hour), Rooms: []booking.Room{a, b}},
+		{Start: start.AddDate(0, 0, 1), End: start.AddDate(0, 0, 1).Add(2 * time.Hour), Rooms: []booking.Room{b}},
+	}
+
+	assert.Equal(t, weekHeader(days), "Mon 14/10 Tue 15/10")
+	assert.Equal(t, weekCells(days, a), ".         #")
+	assert.Equal(t, weekCells(days, b), ".         .")
+}
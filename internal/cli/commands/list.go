@@ -1,24 +1,26 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/directory"
 )
 
 var listJSON bool
 
-func ListCmd(getBS func() booking.BookingService) *cobra.Command {
+func ListCmd(getCtx func() (context.Context, context.CancelFunc), getBS func(context.Context) booking.BookingService) *cobra.Command {
 	ListCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List your current bookings",
 		Long:  `Used to list all upcoming and current bookings`,
 		Run: func(cmd *cobra.Command, args []string) {
-			runList(cmd, args, getBS)
+			runList(cmd, args, getCtx, getBS)
 		},
 	}
 
@@ -27,30 +29,74 @@ func ListCmd(getBS func() booking.BookingService) *cobra.Command {
 	return ListCmd
 }
 
-func runList(cmd *cobra.Command, args []string, getBS func() booking.BookingService) {
-	bookings, err := getBS().MyBookings()
-	if err != nil {
-		fmt.Printf("Failed to get bookings: %v \n", err)
-		os.Exit(1)
+func runList(cmd *cobra.Command, args []string, getCtx func() (context.Context, context.CancelFunc),
+	getBS func(context.Context) booking.BookingService) {
+	ctx, cancel := getCtx()
+	defer cancel()
+
+	bookings, err := getBS(ctx).MyBookings(ctx)
+	if listJSON {
+		showListJSON(bookings, err)
+		return
+	}
+	if err = warnPartial(err); err != nil {
+		Fail("Failed to get bookings", err)
 	}
+	bookings = inTimeZone(bookings, location)
 
-	if !listJSON {
-		fmt.Printf("%-9s %-11s %-15s %s\n", "DATE", "TIME", "ROOM", "TEXT")
-		for _, booking := range bookings {
-			date := formatDateWithWeekday(booking)
-			time := formatTime(booking)
-			text := fmt.Sprintf("\"%s\"", booking.Text)
-			fmt.Printf("%-9s %-11s %-15s %s\n",
-				date,
-				time,
-				booking.Room.Id,
-				text,
-			)
-		}
-	} else {
-		b, _ := json.Marshal(bookings)
-		fmt.Println(string(b))
+	fmt.Printf("%-9s %-11s %-15s %s\n", "DATE", "TIME", "ROOM", "TEXT")
+	for _, booking := range bookings {
+		date := formatDateWithWeekday(booking)
+		time := formatTime(booking)
+		text := fmt.Sprintf("\"%s\"", booking.Text)
+		fmt.Printf("%-9s %-11s %-15s %s\n",
+			date,
+			time,
+			booking.Room.Id,
+			text,
+		)
+	}
+}
+
+// showListJSON prints the bookings as JSON. The providers whose bookings are
+// missing are listed in the output instead of being warned about so that the
+// output stays valid JSON.
+func showListJSON(bookings []booking.Booking, err error) {
+	var partial *directory.PartialError
+	if err != nil && !errors.As(err, &partial) {
+		Fail("Failed to get bookings", err)
+	}
+	b, _ := json.Marshal(listOutput{
+		Bookings: inTimeZone(bookings, location),
+		Errors:   providerErrors(partial),
+	})
+	fmt.Println(string(b))
+}
+
+// listOutput is the output of 'bgc list --json'
+type listOutput struct {
+	Bookings []booking.Booking `json:"bookings"`
+	Errors   []providerError   `json:"errors"`
+}
+
+// providerError tells why the bookings of a provider are missing
+type providerError struct {
+	Provider string `json:"provider"`
+	Error    string `json:"error"`
+}
+
+func providerErrors(partial *directory.PartialError) []providerError {
+	errs := []providerError{}
+	if partial == nil {
+		return errs
+	}
+	for _, provider := range partial.Missing() {
+		errs = append(errs, providerError{
+			Provider: provider,
+			Error:    partial.Errors()[provider].Error(),
+		})
 	}
+	return errs
 }
 
 func formatDateWithWeekday(booking booking.Booking) (date string) {
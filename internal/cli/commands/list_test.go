@@ -1,12 +1,18 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/magiconair/properties/assert"
 
 	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/directory"
+	logfmt "sidus.io/boogrocha/internal/log/fmt"
 )
 
 func TestFormatDateWithWeekdayMonday(t *testing.T) {
@@ -27,3 +33,91 @@ func TestFormatTime(t *testing.T) {
 	booking := booking.Booking{Start: start, End: end}
 	assert.Equal(t, formatTime(booking), "15:04-16:34")
 }
+
+func TestInTimeZone(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	stockholm := time.FixedZone("CEST", 2*60*60)
+	start := time.Date(2020, 9, 15, 10, 0, 0, 0, stockholm)
+	bookings := inTimeZone([]booking.Booking{{Start: start, End: start.Add(2 * time.Hour)}}, est)
+
+	assert.Equal(t, formatTime(bookings[0]), "03:00-05:00")
+	assert.Equal(t, formatDateWithWeekday(bookings[0]), "Tue 15/09")
+}
+
+func TestListOutput(t *testing.T) {
+	bs := directory.NewBookingService(map[string]booking.BookingService{
+		"A": newStaticService([]booking.Booking{{Id: "1"}}, nil),
+		"B": &errorService{err: booking.ErrProviderUnreachable},
+	}, &logfmt.Logger{})
+
+	bookings, err := bs.MyBookings(context.Background())
+	var partial *directory.PartialError
+	assert.Equal(t, errors.As(err, &partial), true)
+
+	b, _ := json.Marshal(listOutput{Bookings: bookings, Errors: providerErrors(partial)})
+	var output struct {
+		Bookings []booking.Booking
+		Errors   []map[string]string
+	}
+	_ = json.Unmarshal(b, &output)
+	assert.Equal(t, len(output.Bookings), 1)
+	assert.Equal(t, output.Errors, []map[string]string{{"provider": "B", "error": "booking provider unreachable"}})
+
+	b, _ = json.Marshal(listOutput{Bookings: inTimeZone(nil, location), Errors: providerErrors(nil)})
+	assert.Equal(t, string(b), `{"bookings":[],"errors":[]}`)
+}
+
+// errorService fails every call with err, or a generic error if err is nil
+type errorService struct {
+	err error
+}
+
+func (es *errorService) fail() error {
+	if es.err != nil {
+		return es.err
+	}
+	return fmt.Errorf("mock error")
+}
+
+func (es *errorService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	return booking.Booking{}, es.fail()
+}
+
+func (es *errorService) UnBook(ctx context.Context, b booking.Booking) error {
+	return es.fail()
+}
+
+func (es *errorService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	return nil, es.fail()
+}
+
+func (es *errorService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	return nil, es.fail()
+}
+
+// staticService books every room without remembering it, and always has
+// the same bookings and rooms available
+type staticService struct {
+	bookings []booking.Booking
+	rooms    []booking.Room
+}
+
+func newStaticService(bookings []booking.Booking, rooms []booking.Room) *staticService {
+	return &staticService{bookings: bookings, rooms: rooms}
+}
+
+func (*staticService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	return b, nil
+}
+
+func (*staticService) UnBook(ctx context.Context, b booking.Booking) error {
+	return nil
+}
+
+func (ss *staticService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	return ss.bookings, nil
+}
+
+func (ss *staticService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	return ss.rooms, nil
+}
@@ -0,0 +1,331 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/ranking"
+	"sidus.io/boogrocha/internal/ranking/file"
+)
+
+func RankingsCmd(getRS func() ranking.RankingService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rankings",
+		Short: "Show and manage your room rankings",
+		Long: `The rankings learn which rooms you prefer for every weekday and time of day
+as you book rooms, and decide the order the available rooms are listed in.
+A room with a low score is listed first.`,
+		Run: nil,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show [day] [time]",
+		Short: "Show the rankings of the rooms",
+		Long:  "Show the global rankings of the rooms, or their rankings for bookings at the given day and time",
+		Args:  dayAndTimeArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runRankingsShow(getRS, args)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "explain {day} {time} [room]",
+		Short: "Explain why the rooms are listed in their order",
+		Args:  cobra.RangeArgs(2, 3),
+		Run: func(cmd *cobra.Command, args []string) {
+			runRankingsExplain(getRS, args)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "reset [room]",
+		Short: "Forget the rankings of a room, or of all rooms",
+		Long:  "Forget the rankings of a room, or of all rooms. The favorite and blocked rooms are kept.",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runRankingsReset(getRS, args)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "export [file]",
+		Short: "Write the rankings as JSON to a file, or to stdout",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runRankingsExport(getRS, args)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "import {file}",
+		Short: "Replace the rankings with exported rankings, - reads stdin",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runRankingsImport(getRS, args[0])
+		},
+	})
+
+	return cmd
+}
+
+// dayAndTimeArgs accepts either no day and time or both of them
+func dayAndTimeArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		return fmt.Errorf("expected both a day and a time, got only %q", args[0])
+	}
+	return cobra.MaximumNArgs(2)(cmd, args)
+}
+
+func getRankings(rs ranking.RankingService) *ranking.Rankings {
+	rankings, err := rs.GetRankings()
+	if err != nil {
+		Fail("Failed to get rankings", err)
+	}
+	return rankings
+}
+
+// dryRunner is implemented by the ranking services of a dry run, which
+// don't save anything
+type dryRunner interface {
+	DryRun() bool
+}
+
+// saveRankings saves the rankings and reports whether they were saved,
+// which they aren't in a dry run
+func saveRankings(rs ranking.RankingService, rankings *ranking.Rankings) bool {
+	if d, ok := rs.(dryRunner); ok && d.DryRun() {
+		fmt.Println("Nothing was saved since it's a dry run")
+		return false
+	}
+	err := rs.SaveRankings(rankings)
+	if err != nil {
+		Fail("Could not save updated rankings", err)
+	}
+	return true
+}
+
+func runRankingsShow(getRS func() ranking.RankingService, args []string) {
+	rankings := getRankings(getRS())
+
+	var start time.Time
+	if len(args) == 2 {
+		start, _ = readArgs(args)
+	}
+	scores := rankedScores(rankings, start)
+	if len(scores) == 0 {
+		fmt.Println("No rankings yet, they are learned as you book rooms")
+		return
+	}
+
+	fmt.Printf("Rankings %s, a low score is good\n", bucketName(scores[0].Bucket.Bucket))
+	fmt.Printf("%-4s %-15s %-20s %-7s %-7s %-10s %s\n", "#", "ROOM", "PROVIDER", "SCORE", "GLOBAL", "LAST USED", "LIST")
+	for i, s := range scores {
+		fmt.Printf("%-4d %-15s %-20s %-7d %-7d %-10s %s\n",
+			i+1,
+			s.Room.Id,
+			s.Room.Provider,
+			s.Bucket.Penalty,
+			s.Global.Penalty,
+			formatLastUsed(s.Global.LastUsed),
+			roomLists(rankings, s),
+		)
+	}
+}
+
+func runRankingsExplain(getRS func() ranking.RankingService, args []string) {
+	rankings := getRankings(getRS())
+	start, _ := readArgs(args)
+
+	// Rooms are explained by their position among all ranked rooms, a room
+	// without rankings is ranked as if its penalties were 0
+	scores := rankedScores(rankings, start)
+	positions := make([]string, len(scores))
+	for i := range scores {
+		positions[i] = strconv.Itoa(i + 1)
+	}
+	if len(args) == 3 {
+		var matching []ranking.Score
+		var matchingPositions []string
+		for i, s := range scores {
+			if strings.EqualFold(s.Room.Id, args[2]) {
+				matching = append(matching, s)
+				matchingPositions = append(matchingPositions, positions[i])
+			}
+		}
+		if len(matching) == 0 {
+			matching = append(matching, rankings.Score(booking.Room{Id: args[2]}, start))
+			matchingPositions = append(matchingPositions, "-")
+		}
+		scores, positions = matching, matchingPositions
+	}
+
+	fmt.Printf("Rooms are ranked %s by:\n", bucketName(ranking.BucketOf(start)))
+	fmt.Println("  1. favorite rooms first")
+	fmt.Printf("  2. the lowest penalty %s\n", bucketName(ranking.BucketOf(start)))
+	fmt.Println("  3. the lowest global penalty")
+	fmt.Println("  4. the name of the room, in reverse order")
+	fmt.Println("Rooms without rankings have no penalties.")
+	if rankings.HalfLife > 0 {
+		fmt.Printf("Penalties halve every %s since the room was last used.\n", rankings.HalfLife)
+	}
+	fmt.Println()
+
+	for i, s := range scores {
+		fmt.Printf("%s. %s\n", positions[i], s.Room.Id)
+		for _, line := range explainScore(rankings, s) {
+			fmt.Printf("   %s\n", line)
+		}
+	}
+}
+
+func runRankingsReset(getRS func() ranking.RankingService, args []string) {
+	rs := getRS()
+	rankings := getRankings(rs)
+
+	if len(args) == 0 {
+		rankings.Buckets = nil
+		if saveRankings(rs, rankings) {
+			fmt.Println("Reset the rankings of all rooms")
+		}
+		return
+	}
+
+	var matching []booking.Room
+	seen := make(map[booking.Room]bool)
+	for _, ranks := range rankings.Buckets {
+		for room := range ranks {
+			if strings.EqualFold(room.Id, args[0]) && !seen[room] {
+				seen[room] = true
+				matching = append(matching, room)
+			}
+		}
+	}
+	if len(matching) == 0 {
+		fmt.Printf("%s has no rankings\n", args[0])
+		os.Exit(1)
+	}
+
+	for _, room := range matching {
+		rankings.Reset(room)
+	}
+	if saveRankings(rs, rankings) {
+		fmt.Printf("Reset the rankings of %s\n", args[0])
+	}
+}
+
+func runRankingsExport(getRS func() ranking.RankingService, args []string) {
+	data, err := file.Encode(getRankings(getRS()))
+	if err != nil {
+		Fail("Failed to export rankings", err)
+	}
+	var out bytes.Buffer
+	err = json.Indent(&out, data, "", "  ")
+	if err != nil {
+		Fail("Failed to export rankings", err)
+	}
+	out.WriteString("\n")
+
+	if len(args) == 0 {
+		fmt.Print(out.String())
+		return
+	}
+	err = ioutil.WriteFile(args[0], out.Bytes(), 0644)
+	if err != nil {
+		Fail("Failed to export rankings", err)
+	}
+	fmt.Printf("Exported the rankings to %s\n", args[0])
+}
+
+func runRankingsImport(getRS func() ranking.RankingService, path string) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		Fail("Failed to import rankings", err)
+	}
+
+	imported, err := file.Decode(data, time.Now())
+	if err != nil {
+		Fail("Failed to import rankings", err)
+	}
+
+	if saveRankings(getRS(), imported) {
+		fmt.Printf("Imported the rankings of %d rooms\n", len(imported.Rooms(ranking.Global)))
+	}
+}
+
+// rankedScores returns the scores of the ranked rooms in the order Sort
+// lists them for bookings starting at start
+func rankedScores(rankings *ranking.Rankings, start time.Time) []ranking.Score {
+	bucket := ranking.Global
+	if !start.IsZero() {
+		bucket = ranking.BucketOf(start)
+	}
+	rooms := rankings.Sort(rankings.Rooms(bucket), start)
+
+	scores := make([]ranking.Score, 0, len(rooms))
+	for _, room := range rooms {
+		scores = append(scores, rankings.Score(room, start))
+	}
+	return scores
+}
+
+// explainScore describes each of the contributions to the score
+func explainScore(rankings *ranking.Rankings, s ranking.Score) []string {
+	var lines []string
+	if s.Favorite {
+		lines = append(lines, "favorite room")
+	}
+	if rankings.IsBlocked(s.Room) {
+		lines = append(lines, "blocked room, left out of the listed rooms")
+	}
+	for _, c := range []ranking.Contribution{s.Bucket, s.Global} {
+		lines = append(lines, fmt.Sprintf("penalty %s: %s", bucketName(c.Bucket), formatContribution(c)))
+	}
+	return lines
+}
+
+func formatContribution(c ranking.Contribution) string {
+	if c.LastUsed.IsZero() {
+		return fmt.Sprintf("%d", c.Penalty)
+	}
+	if c.Stored == c.Penalty {
+		return fmt.Sprintf("%d, last used %s", c.Penalty, formatLastUsed(c.LastUsed))
+	}
+	return fmt.Sprintf("%d, decayed from %d when last used %s", c.Penalty, c.Stored, formatLastUsed(c.LastUsed))
+}
+
+func bucketName(bucket ranking.Bucket) string {
+	if bucket == ranking.Global {
+		return "globally"
+	}
+	return fmt.Sprintf("on %s", strings.Replace(string(bucket), "-", " ", 1))
+}
+
+func formatLastUsed(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.In(location).Format("2006-01-02")
+}
+
+// roomLists tells which of the favorite and blocked rooms the room is
+func roomLists(rankings *ranking.Rankings, s ranking.Score) string {
+	var lists []string
+	if s.Favorite {
+		lists = append(lists, "favorite")
+	}
+	if rankings.IsBlocked(s.Room) {
+		lists = append(lists, "blocked")
+	}
+	return strings.Join(lists, ", ")
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/ranking"
+)
+
+func TestRankedScores(t *testing.T) {
+	a := booking.Room{Provider: "A", Id: "a"}
+	b := booking.Room{Provider: "A", Id: "b"}
+	c := booking.Room{Provider: "A", Id: "c"}
+	monday := time.Date(2019, 10, 14, 8, 0, 0, 0, time.Local)
+
+	rankings := &ranking.Rankings{
+		Buckets: map[ranking.Bucket]ranking.Ranks{
+			ranking.Global:           {a: {Penalty: 1}, b: {Penalty: 2}},
+			ranking.BucketOf(monday): {a: {Penalty: 5}, c: {Penalty: 1}},
+		},
+		Favorites: []ranking.Pattern{"b"},
+	}
+
+	var rooms []booking.Room
+	for _, s := range rankedScores(rankings, time.Time{}) {
+		rooms = append(rooms, s.Room)
+	}
+	assert.Equal(t, rooms, []booking.Room{b, a})
+
+	rooms = nil
+	for _, s := range rankedScores(rankings, monday) {
+		rooms = append(rooms, s.Room)
+	}
+	assert.Equal(t, rooms, []booking.Room{b, c, a})
+}
+
+func TestExplainScore(t *testing.T) {
+	room := booking.Room{Provider: "A", Id: "EG-2515"}
+	monday := time.Date(2019, 10, 14, 8, 0, 0, 0, time.Local)
+	used := time.Date(2019, 9, 14, 8, 0, 0, 0, time.Local)
+
+	rankings := &ranking.Rankings{Blocked: []ranking.Pattern{"EG-*"}}
+	s := ranking.Score{
+		Room:     room,
+		Favorite: true,
+		Bucket:   ranking.Contribution{Bucket: ranking.BucketOf(monday)},
+		Global:   ranking.Contribution{Bucket: ranking.Global, Penalty: 5, Stored: 10, LastUsed: used},
+	}
+
+	assert.Equal(t, explainScore(rankings, s), []string{
+		"favorite room",
+		"blocked room, left out of the listed rooms",
+		"penalty on monday morning: 0",
+		"penalty globally: 5, decayed from 10 when last used 2019-09-14",
+	})
+}
+
+// savingService counts the rankings it saves
+type savingService struct {
+	saved  int
+	dryRun bool
+}
+
+func (s *savingService) GetRankings() (*ranking.Rankings, error) {
+	return &ranking.Rankings{}, nil
+}
+
+func (s *savingService) SaveRankings(rankings *ranking.Rankings) error {
+	s.saved++
+	return nil
+}
+
+func (s *savingService) DryRun() bool {
+	return s.dryRun
+}
+
+func TestSaveRankingsDryRun(t *testing.T) {
+	rs := &savingService{}
+	assert.Equal(t, saveRankings(rs, &ranking.Rankings{}), true)
+	assert.Equal(t, rs.saved, 1)
+
+	rs.dryRun = true
+	assert.Equal(t, saveRankings(rs, &ranking.Rankings{}), false)
+	assert.Equal(t, rs.saved, 1, "Nothing should be saved in a dry run")
+}
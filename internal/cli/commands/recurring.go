@@ -0,0 +1,241 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/directory"
+	"sidus.io/boogrocha/internal/filter"
+	"sidus.io/boogrocha/internal/ranking"
+)
+
+type interval struct {
+	start time.Time
+	end   time.Time
+}
+
+type occurrenceResult struct {
+	interval interval
+	room     booking.Room
+	id       string
+	fallback bool
+	err      error
+}
+
+func runRecurring(cmd *cobra.Command, args []string, getCtx func() (context.Context, context.CancelFunc),
+	withTimeout func(context.Context) (context.Context, context.CancelFunc),
+	getBS func(context.Context) booking.BookingService, getRS func() ranking.RankingService,
+	campus string, roomSize int, roomName string, message string, until string, ignoreLists bool) {
+	parent, cancel := getCtx()
+	defer cancel()
+	ctx, cancelTimeout := withTimeout(parent)
+	defer cancelTimeout()
+
+	startDate, endDate := readArgs(args)
+	if startDate.Before(time.Now()) {
+		fmt.Printf("booking has to be in the future\n")
+		return
+	}
+
+	untilDate, err := extractDate(until)
+	if err != nil {
+		fmt.Printf("couldn't interpret \"%s\" as a date\n", until)
+		os.Exit(1)
+	}
+
+	occurrences := weeklyOccurrences(startDate, endDate, untilDate)
+	if len(occurrences) == 0 {
+		fmt.Println("the end date has to be after the first booking")
+		os.Exit(1)
+	}
+
+	bs := getBS(ctx)
+
+	rs := getRS()
+	rankings, err := rs.GetRankings()
+	if err != nil {
+		fmt.Printf("Failed to get rankings: %v\n", err)
+	}
+	prefs := preferences(rankings, ignoreLists)
+	filters := getFilters(cmd, campus, roomSize)
+
+	// The preferred room is either specified by name or picked among the
+	// rooms available at the first occurrence.
+	preferred := func(r booking.Room) bool {
+		return strings.ToLower(r.Id) == strings.ToLower(roomName)
+	}
+	updateRankings := func() {}
+	if !cmd.Flags().Changed(RoomFlagName) {
+		available, err := bs.Available(ctx, occurrences[0].start, occurrences[0].end)
+		if err = warnPartial(err); err != nil {
+			Fail("Couldn't get available rooms", err)
+		}
+		if prefs != nil {
+			available = prefs.Sort(available, occurrences[0].start)
+		}
+		available = prefs.Filter(filter.Filter(available, filters))
+
+		showAvailable(available, cmd.Flags().Changed(SizeFlagName))
+
+		input, err := prompt("Room to book")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		n, err := strconv.Atoi(input)
+		n--
+		if err != nil || n < 0 || n >= len(available) {
+			fmt.Printf("Invalid Room\n")
+			os.Exit(1)
+		}
+		selected := available[n]
+		preferred = func(r booking.Room) bool {
+			return r == selected
+		}
+
+		// The rankings only learn from the selection once a room is booked
+		pool := available
+		updateRankings = func() {
+			rankings.Update(selected, pool, occurrences[0].start)
+			err := rs.SaveRankings(rankings)
+			if err != nil {
+				fmt.Printf("Could not save updated rankings: %v\n", err)
+			}
+		}
+	}
+
+	if !cmd.Flags().Changed(MessageFlagName) {
+		message, err = prompt("Message to add with the bookings (default: empty)")
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println("No booking was made")
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Booking %d occurrences...\n", len(occurrences))
+	var results []occurrenceResult
+	for _, occurrence := range occurrences {
+		// Every occurrence gets the whole timeout, so that booking many of
+		// them doesn't run out of time
+		occurrenceCtx, cancelOccurrence := withTimeout(parent)
+		results = append(results, bookOccurrence(occurrenceCtx, bs, prefs, filters, preferred, occurrence, message))
+		cancelOccurrence()
+	}
+
+	dryRun := isDryRun(bs)
+	failed := showOccurrenceResults(results, dryRun)
+	if failed < len(results) && rankings != nil {
+		updateRankings()
+	}
+	if failed < len(results) && !dryRun {
+		fmt.Println("Undo a booking with 'bgc delete id {id}'")
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d bookings failed\n", failed, len(results))
+		os.Exit(1)
+	}
+	fmt.Printf("Booked all %d occurrences successfully!\n", len(results))
+}
+
+// bookOccurrence books the preferred room for the given occurrence, falling
+// back to the best ranked available room if the preferred room is taken.
+func bookOccurrence(ctx context.Context, bs booking.BookingService, rankings *ranking.Rankings, filters []filter.RoomFilter,
+	preferred func(booking.Room) bool, occurrence interval, message string) occurrenceResult {
+	result := occurrenceResult{interval: occurrence}
+
+	available, err := bs.Available(ctx, occurrence.start, occurrence.end)
+	// A missing provider only leaves fewer rooms to choose from, and the
+	// report shows which rooms were booked
+	if err != nil && !errors.Is(err, directory.ErrPartialResult) {
+		result.err = err
+		return result
+	}
+
+	found := false
+	for _, r := range available {
+		if preferred(r) {
+			result.room = r
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		// Sorted before filtered, like the rooms to pick from
+		if rankings != nil {
+			available = rankings.Sort(available, occurrence.start)
+		}
+		available = rankings.Filter(filter.Filter(available, filters))
+		if len(available) == 0 {
+			result.err = fmt.Errorf("no rooms available")
+			return result
+		}
+		result.room = available[0]
+		result.fallback = true
+	}
+
+	created, err := bs.Book(ctx, booking.Booking{
+		Room:  result.room,
+		Start: occurrence.start,
+		End:   occurrence.end,
+		Text:  message,
+	})
+	result.id, result.err = created.Id, err
+	return result
+}
+
+func showOccurrenceResults(results []occurrenceResult, dryRun bool) (failed int) {
+	fmt.Printf("%-9s %-11s %-15s %-10s %s\n", "DATE", "TIME", "ROOM", "ID", "STATUS")
+	for _, result := range results {
+		b := booking.Booking{Start: result.interval.start, End: result.interval.end}
+		status := "booked"
+		if result.err != nil {
+			status = fmt.Sprintf("failed: %v", result.err)
+			if hint, _ := describeError(result.err); hint != "" {
+				status = fmt.Sprintf("failed: %s", hint)
+			}
+			failed++
+		} else if dryRun {
+			status = "not booked (dry run)"
+		} else if result.fallback {
+			status = "booked (preferred room was taken)"
+		}
+		fmt.Printf("%-9s %-11s %-15s %-10s %s\n",
+			formatDateWithWeekday(b),
+			formatTime(b),
+			result.room.Id,
+			result.id,
+			status,
+		)
+	}
+	return failed
+}
+
+// weeklyOccurrences returns the interval between start and end repeated
+// every week up until and including the day of until.
+func weeklyOccurrences(start, end, until time.Time) []interval {
+	last := time.Date(until.Year(), until.Month(), until.Day()+1, 0, 0, 0, 0, until.Location())
+
+	var occurrences []interval
+	for week := 0; ; week++ {
+		// AddDate keeps the wall clock time across daylight saving changes
+		occurrence := interval{
+			start: start.AddDate(0, 0, 7*week),
+			end:   end.AddDate(0, 0, 7*week),
+		}
+		if !occurrence.start.Before(last) {
+			break
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences
+}
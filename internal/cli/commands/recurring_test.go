@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestWeeklyOccurrences(t *testing.T) {
+	start := time.Date(2019, 10, 15, 13, 0, 0, 0, time.UTC)
+	end := time.Date(2019, 10, 15, 15, 0, 0, 0, time.UTC)
+
+	occurrences := weeklyOccurrences(start, end, time.Date(2019, 11, 5, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, len(occurrences), 4)
+	assert.Equal(t, occurrences[0].start, start)
+	assert.Equal(t, occurrences[3].start, time.Date(2019, 11, 5, 13, 0, 0, 0, time.UTC))
+	assert.Equal(t, occurrences[3].end, time.Date(2019, 11, 5, 15, 0, 0, 0, time.UTC))
+}
+
+func TestWeeklyOccurrencesUntilBeforeStart(t *testing.T) {
+	start := time.Date(2019, 10, 15, 13, 0, 0, 0, time.UTC)
+	end := time.Date(2019, 10, 15, 15, 0, 0, 0, time.UTC)
+
+	occurrences := weeklyOccurrences(start, end, time.Date(2019, 10, 14, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, len(occurrences), 0)
+}
+
+func TestWeeklyOccurrencesKeepsWallClockTime(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	start := time.Date(2019, 10, 22, 13, 0, 0, 0, stockholm)
+	end := time.Date(2019, 10, 22, 15, 0, 0, 0, stockholm)
+
+	occurrences := weeklyOccurrences(start, end, time.Date(2019, 10, 29, 0, 0, 0, 0, stockholm))
+	assert.Equal(t, len(occurrences), 2)
+	assert.Equal(t, occurrences[1].start.Hour(), 13)
+	assert.Equal(t, occurrences[1].end.Hour(), 15)
+}
@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"sidus.io/boogrocha/internal/booking/catalog"
+	"sidus.io/boogrocha/internal/ranking"
+)
+
+// roomList is a list of room patterns kept with the rankings
+type roomList struct {
+	name     string
+	patterns func(rankings *ranking.Rankings) *[]ranking.Pattern
+}
+
+var favoriteRooms = roomList{
+	name: "favorite",
+	patterns: func(rankings *ranking.Rankings) *[]ranking.Pattern {
+		return &rankings.Favorites
+	},
+}
+
+var blockedRooms = roomList{
+	name: "blocked",
+	patterns: func(rankings *ranking.Rankings) *[]ranking.Pattern {
+		return &rankings.Blocked
+	},
+}
+
+func RoomsCmd(getCtx func() (context.Context, context.CancelFunc), getCS func() *catalog.Service,
+	getRS func() ranking.RankingService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rooms",
+		Short: "Manage the room catalog and your favorite and blocked rooms",
+		Long: fmt.Sprintf(`The room catalog knows the seats and campus of the rooms.
+Corrections and notes can be added to %s in the config folder.
+
+Favorite rooms are always listed first and blocked rooms are left out of
+book, find and free. Rooms can be given by name, with the wildcards * and ?, or as a
+regular expression enclosed in slashes, such as "/^EG-/".`, catalog.OverrideFile),
+		Run: nil,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "sync",
+		Short: "Refresh the room catalog",
+		Long:  "Fetch the latest room catalog even if the cached copy isn't old yet",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runRoomsSync(getCtx, getCS)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the rooms in the catalog",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runRoomsList(getCtx, getCS)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "favorite [room]",
+		Short: "Always list a room first, or show the favorite rooms",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runRoomList(getRS, favoriteRooms, args)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "unfavorite {room}",
+		Short: "Remove a room from the favorite rooms",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runRoomListRemove(getRS, favoriteRooms, args[0])
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "block [room]",
+		Short: "Leave a room out of book, find and free, or show the blocked rooms",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runRoomList(getRS, blockedRooms, args)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "unblock {room}",
+		Short: "Remove a room from the blocked rooms",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runRoomListRemove(getRS, blockedRooms, args[0])
+		},
+	})
+
+	return cmd
+}
+
+func runRoomsSync(getCtx func() (context.Context, context.CancelFunc), getCS func() *catalog.Service) {
+	ctx, cancel := getCtx()
+	defer cancel()
+
+	rooms, changed, err := getCS().Sync(ctx)
+	if err != nil {
+		Fail("Failed to sync the room catalog", err)
+	}
+	if changed {
+		fmt.Printf("Room catalog updated, %d rooms\n", len(rooms))
+	} else {
+		fmt.Printf("Room catalog already up to date, %d rooms\n", len(rooms))
+	}
+}
+
+func runRoomsList(getCtx func() (context.Context, context.CancelFunc), getCS func() *catalog.Service) {
+	ctx, cancel := getCtx()
+	defer cancel()
+
+	rooms, err := getCS().Get(ctx)
+	if err != nil {
+		Fail("Failed to get the room catalog", err)
+	}
+
+	fmt.Printf("%-15s %-5s %-12s %-10s %-5s %s\n", "ROOM", "SEATS", "CAMPUS", "BUILDING", "FLOOR", "NOTES")
+	for _, name := range rooms.Names() {
+		info := rooms[name]
+		fmt.Printf("%-15s %-5d %-12s %-10s %-5s %s\n",
+			name,
+			info.Seats,
+			info.Campus,
+			info.Building,
+			info.Floor,
+			info.Notes,
+		)
+	}
+}
+
+// runRoomList adds the room in args to the list, or shows the list if no
+// room is given
+func runRoomList(getRS func() ranking.RankingService, list roomList, args []string) {
+	rs := getRS()
+	rankings, err := rs.GetRankings()
+	if err != nil {
+		Fail("Failed to get rankings", err)
+	}
+	patterns := list.patterns(rankings)
+
+	if len(args) == 0 {
+		if len(*patterns) == 0 {
+			fmt.Printf("No %s rooms\n", list.name)
+		}
+		for _, p := range *patterns {
+			fmt.Println(p)
+		}
+		return
+	}
+
+	pattern := ranking.Pattern(args[0])
+	if _, err := pattern.Compile(); err != nil {
+		fmt.Printf("\"%s\" is not a valid room pattern: %v\n", pattern, err)
+		os.Exit(1)
+	}
+	for _, p := range *patterns {
+		if strings.EqualFold(string(p), string(pattern)) {
+			fmt.Printf("%s is already a %s room\n", pattern, list.name)
+			return
+		}
+	}
+
+	*patterns = append(*patterns, pattern)
+	if saveRankings(rs, rankings) {
+		fmt.Printf("Added %s to the %s rooms\n", pattern, list.name)
+	}
+}
+
+func runRoomListRemove(getRS func() ranking.RankingService, list roomList, room string) {
+	rs := getRS()
+	rankings, err := rs.GetRankings()
+	if err != nil {
+		Fail("Failed to get rankings", err)
+	}
+	patterns := list.patterns(rankings)
+
+	kept := (*patterns)[:0]
+	for _, p := range *patterns {
+		if !strings.EqualFold(string(p), room) {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(*patterns) {
+		fmt.Printf("%s is not a %s room\n", room, list.name)
+		os.Exit(1)
+	}
+
+	*patterns = kept
+	if saveRankings(rs, rankings) {
+		fmt.Printf("Removed %s from the %s rooms\n", room, list.name)
+	}
+}
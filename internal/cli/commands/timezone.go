@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+// location is the time zone dates are read in and times are shown in
+var location = time.Local
+
+// SetTimeZone sets the time zone dates are read in and times are shown in,
+// without changing the local time zone of the process
+func SetTimeZone(loc *time.Location) {
+	location = loc
+}
+
+// now is the current time in the time zone dates are read in
+func now() time.Time {
+	return time.Now().In(location)
+}
+
+// inTimeZone converts the times of the bookings from the time zone of
+// their provider to the one they are shown in
+func inTimeZone(bookings []booking.Booking, loc *time.Location) []booking.Booking {
+	converted := make([]booking.Booking, len(bookings))
+	for i, b := range bookings {
+		b.Start, b.End = b.Start.In(loc), b.End.In(loc)
+		converted[i] = b
+	}
+	return converted
+}
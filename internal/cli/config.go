@@ -8,13 +8,21 @@ import (
 	"github.com/spf13/viper"
 )
 
+// configPath returns the folder where the config and other state is kept
+func configPath() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/.%s/", home, ApplicationName), nil
+}
+
 func loadConfig() error {
 
-	home, err := homedir.Dir()
+	configPath, err := configPath()
 	if err != nil {
 		return err
 	}
-	configPath := fmt.Sprintf("%s/.%s/", home, ApplicationName)
 
 	viper.SetConfigName("config")   // name of config file (without extension)
 	viper.AddConfigPath(configPath) // call multiple times to add many search paths
@@ -22,6 +30,24 @@ func loadConfig() error {
 	viper.SetDefault("chalmers.cid", "")
 	viper.SetDefault("chalmers.pass", "")
 	viper.SetDefault("chalmers.campus", "johanneberg")
+	viper.SetDefault("provider", "chalmers")
+	viper.SetDefault("local.path", "")
+	viper.SetDefault("timeout", "0")
+	viper.SetDefault("timezone", "Local")
+	viper.SetDefault("timeedit.session_lifetime", "12h")
+	viper.SetDefault("timeedit.retries", 3)
+	viper.SetDefault("timeedit.min_backoff", "200ms")
+	viper.SetDefault("timeedit.max_backoff", "5s")
+	viper.SetDefault("timeedit.requests_per_second", 5)
+	viper.SetDefault("timeedit.burst", 10)
+	viper.SetDefault("providers.timeout", "30s")
+	viper.SetDefault("providers.grace", "2s")
+	viper.SetDefault("cache.disk", true)
+	viper.SetDefault("cache.available_ttl", "1m")
+	viper.SetDefault("cache.bookings_ttl", "30s")
+	viper.SetDefault("rankings.half_life", "2160h")
+	viper.SetDefault("catalog.url", "https://boogrocha.sidus.io/rooms.json")
+	viper.SetDefault("catalog.max_age", "24h")
 
 	// Create config folder
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
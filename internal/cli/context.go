@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Time given to a command to wind down after being interrupted before
+// the process is terminated, e.g. when it is blocked waiting for input.
+const interruptGracePeriod = 2 * time.Second
+
+// getContext returns a context which is cancelled when the user interrupts
+// the command with Ctrl-C. It's only bounded if a timeout is configured, as
+// the timeout also runs while the command waits for the user to answer
+// prompts.
+func getContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := getInterruptibleContext()
+	ctx, cancelTimeout := withTimeout(ctx)
+	return ctx, func() {
+		cancelTimeout()
+		cancel()
+	}
+}
+
+// withTimeout bounds ctx by the configured timeout, if there is one
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := viper.GetDuration("timeout"); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// getInterruptibleContext returns a context which is cancelled when the user
+// interrupts the command with Ctrl-C, without any timeout
+func getInterruptibleContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+
+		select {
+		case <-signals:
+		case <-time.After(interruptGracePeriod):
+		}
+		fmt.Println("Interrupted")
+		os.Exit(130)
+	}()
+
+	return ctx, cancel
+}
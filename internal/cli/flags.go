@@ -1,14 +1,37 @@
 package cli
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 var user string
+var timeout time.Duration
+var timeZone string
+var provider string
+var dryRun bool
 
 func loadFlags() {
 	BgcCmd.PersistentFlags().StringVarP(&user, "cid", "", "", "Manually specify the user")
+	BgcCmd.PersistentFlags().DurationVarP(&timeout, "timeout", "", 0, "Abort the command if it takes longer than this, including prompts (e.g. 30s, 0 to disable)")
+	BgcCmd.PersistentFlags().StringVarP(&provider, "provider", "", "", "Where rooms are booked, either chalmers or local (a file for offline use)")
+	BgcCmd.PersistentFlags().BoolVarP(&dryRun, "dry-run", "", false, "Show what would be booked or deleted without doing it")
+	BgcCmd.PersistentFlags().StringVarP(&timeZone, "tz", "", "", "Time zone to read and show times in (e.g. Europe/Stockholm, defaults to the local time zone)")
 }
 
 func bindFlags() error {
 	err := viper.BindPFlag("chalmers.cid", BgcCmd.PersistentFlags().Lookup("cid"))
-	return err
+	if err != nil {
+		return err
+	}
+	err = viper.BindPFlag("timeout", BgcCmd.PersistentFlags().Lookup("timeout"))
+	if err != nil {
+		return err
+	}
+	err = viper.BindPFlag("provider", BgcCmd.PersistentFlags().Lookup("provider"))
+	if err != nil {
+		return err
+	}
+	return viper.BindPFlag("timezone", BgcCmd.PersistentFlags().Lookup("tz"))
 }
@@ -1,26 +1,43 @@
 package cli
 
 import (
-	"fmt"
 	"os"
 
-	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
 
 	"sidus.io/boogrocha/internal/ranking"
 	"sidus.io/boogrocha/internal/ranking/file"
 )
 
 func getRankingService() ranking.RankingService {
-	home, err := homedir.Dir()
+	path, err := configPath()
 	if err != nil {
 		// TODO
 		os.Exit(1)
 	}
-	path := fmt.Sprintf("%s/.%s/", home, ApplicationName)
-	rs, err := file.NewRankingService(path)
+	rs, err := file.NewRankingServiceWithOptions(path, file.Options{
+		HalfLife: viper.GetDuration("rankings.half_life"),
+	})
 	if err != nil {
 		// TODO
 		os.Exit(1)
 	}
+	if dryRun {
+		return dryRunRankingService{rs}
+	}
 	return rs
 }
+
+// dryRunRankingService reads the rankings but never saves them
+type dryRunRankingService struct {
+	ranking.RankingService
+}
+
+func (dryRunRankingService) SaveRankings(rankings *ranking.Rankings) error {
+	return nil
+}
+
+// DryRun tells the commands that nothing is saved
+func (dryRunRankingService) DryRun() bool {
+	return true
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"sidus.io/boogrocha/internal/cli/commands"
+)
+
+// setTimeZone passes the configured time zone on to the commands, so that
+// dates are both read and shown in it
+func setTimeZone(cmd *cobra.Command, args []string) {
+	name := viper.GetString("timezone")
+	if name == "" || name == "Local" {
+		return
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		fmt.Printf("Unknown time zone %q: %v\n", name, err)
+		os.Exit(1)
+	}
+	commands.SetTimeZone(location)
+}
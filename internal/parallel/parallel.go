@@ -0,0 +1,56 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Do calls f for every index from 0 up to n, running at most limit of the
+// calls at the same time, or all of them if limit isn't positive. Once a call
+// fails the context of the other calls is cancelled and the calls that
+// haven't started are skipped. The error of the call that failed first is
+// returned rather than the cancellations it caused.
+func Do(ctx context.Context, n int, limit int, f func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	errs := make([]error, n)
+	semaphore := make(chan struct{}, limit)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+			errs[i] = f(ctx, i)
+			if errs[i] != nil {
+				// No point in running the rest
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo(t *testing.T) {
+	results := make([]int, 10)
+	err := Do(context.Background(), len(results), 3, func(ctx context.Context, i int) error {
+		results[i] = i * i
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 4, 9, 16, 25, 36, 49, 64, 81}, results)
+}
+
+func TestDo_Limit(t *testing.T) {
+	var mutex sync.Mutex
+	running, max := 0, 0
+	err := Do(context.Background(), 20, 3, func(ctx context.Context, i int) error {
+		mutex.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		mutex.Unlock()
+
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, max <= 3, "At most 3 calls should run at the same time, %d did", max)
+}
+
+func TestDo_Error(t *testing.T) {
+	failed := errors.New("failed")
+	err := Do(context.Background(), 10, 2, func(ctx context.Context, i int) error {
+		if i == 0 {
+			return failed
+		}
+		// The other calls wait until they are cancelled by the failure
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.Equal(t, failed, err, "The failure should be reported rather than the cancellations")
+}
+
+func TestDo_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	err := Do(ctx, 5, 1, func(ctx context.Context, i int) error {
+		calls++
+		return nil
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 0, calls)
+}
+
+func TestDo_Empty(t *testing.T) {
+	assert.NoError(t, Do(context.Background(), 0, 4, func(ctx context.Context, i int) error {
+		return errors.New("shouldn't be called")
+	}))
+}
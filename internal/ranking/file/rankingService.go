@@ -1,39 +1,115 @@
 package file
 
 import (
+	"bytes"
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"sidus.io/boogrocha/internal/booking"
 	"sidus.io/boogrocha/internal/ranking"
 )
 
+// Version of the rankings file. Files from before versions were introduced
+// are a flat array of the global ranks, and files from before version 3 don't
+// know when the rooms were last used.
+const version = 3
+
+type rankingsFile struct {
+	Version   int
+	Global    []rankedRoom
+	Buckets   map[ranking.Bucket][]rankedRoom
+	Favorites []ranking.Pattern `json:",omitempty"`
+	Blocked   []ranking.Pattern `json:",omitempty"`
+}
+
 type rankedRoom struct {
-	Room booking.Room
-	Rank uint64
+	Room     booking.Room
+	Rank     uint64
+	LastUsed time.Time
 }
 
-func rankedRoomsFrom(ranks ranking.Rankings) []rankedRoom {
+func rankedRoomsFrom(ranks ranking.Ranks) []rankedRoom {
 	var rankedRooms []rankedRoom
 	for room, rank := range ranks {
 		rankedRooms = append(rankedRooms, rankedRoom{
-			Room: room,
-			Rank: rank,
+			Room:     room,
+			Rank:     rank.Penalty,
+			LastUsed: rank.LastUsed,
 		})
 	}
 	return rankedRooms
 }
 
-func rankingsFrom(rankedRooms []rankedRoom) ranking.Rankings {
-	rankings := make(ranking.Rankings)
+func ranksFrom(rankedRooms []rankedRoom) ranking.Ranks {
+	ranks := make(ranking.Ranks)
 	for _, rRoom := range rankedRooms {
-		rankings[rRoom.Room] = rRoom.Rank
+		ranks[rRoom.Room] = ranking.Rank{
+			Penalty:  rRoom.Rank,
+			LastUsed: rRoom.LastUsed,
+		}
+	}
+	return ranks
+}
+
+func fileFrom(rankings *ranking.Rankings) rankingsFile {
+	file := rankingsFile{
+		Version:   version,
+		Global:    rankedRoomsFrom(rankings.Buckets[ranking.Global]),
+		Buckets:   make(map[ranking.Bucket][]rankedRoom),
+		Favorites: rankings.Favorites,
+		Blocked:   rankings.Blocked,
+	}
+	for bucket, ranks := range rankings.Buckets {
+		if bucket != ranking.Global {
+			file.Buckets[bucket] = rankedRoomsFrom(ranks)
+		}
+	}
+	return file
+}
+
+func bucketsFrom(file rankingsFile) map[ranking.Bucket]ranking.Ranks {
+	buckets := map[ranking.Bucket]ranking.Ranks{
+		ranking.Global: ranksFrom(file.Global),
+	}
+	for bucket, rankedRooms := range file.Buckets {
+		buckets[bucket] = ranksFrom(rankedRooms)
 	}
-	return rankings
+	return buckets
+}
+
+// migrate sets when the rooms were last used in files written before it was
+// stored, to when the file was last written
+func migrate(file *rankingsFile, modified time.Time) {
+	rankedRooms := [][]rankedRoom{file.Global}
+	for _, rooms := range file.Buckets {
+		rankedRooms = append(rankedRooms, rooms)
+	}
+	for _, rooms := range rankedRooms {
+		for i := range rooms {
+			if rooms[i].LastUsed.IsZero() {
+				rooms[i].LastUsed = modified
+			}
+		}
+	}
+}
+
+type Options struct {
+	// HalfLife is how long it takes for the penalties of rooms to halve,
+	// ranking.DefaultHalfLife is used if it isn't set and a negative half-life
+	// disables decay
+	HalfLife time.Duration
 }
 
 func NewRankingService(path string) (ranking.RankingService, error) {
+	return NewRankingServiceWithOptions(path, Options{})
+}
+
+func NewRankingServiceWithOptions(path string, opts Options) (ranking.RankingService, error) {
+	if opts.HalfLife == 0 {
+		opts.HalfLife = ranking.DefaultHalfLife
+	}
 
 	// Create folder if it doesnt exist
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -43,46 +119,79 @@ func NewRankingService(path string) (ranking.RankingService, error) {
 		}
 	}
 
-	fullPath := path + "rankings.json"
+	rs := RankingService{
+		path:     path + "rankings.json",
+		halfLife: opts.HalfLife,
+	}
 
 	// Write rankings file if it doesn't exists
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		file, err := os.Create(fullPath)
-		if err != nil {
-			return nil, err
-		}
-		_, err = file.Write([]byte("[]"))
+	if _, err := os.Stat(rs.path); os.IsNotExist(err) {
+		err = rs.SaveRankings(&ranking.Rankings{})
 		if err != nil {
 			return nil, err
 		}
 	}
-	return RankingService{
-		path: fullPath,
-	}, nil
+	return rs, nil
 }
 
 type RankingService struct {
-	path string
+	path     string
+	halfLife time.Duration
 }
 
-func (rs RankingService) GetRankings() (ranking.Rankings, error) {
-	bytes, err := ioutil.ReadFile(rs.path)
+func (rs RankingService) GetRankings() (*ranking.Rankings, error) {
+	info, err := os.Stat(rs.path)
 	if err != nil {
 		return nil, err
 	}
-
-	var data []rankedRoom
-	err = json.Unmarshal(bytes, &data)
+	data, err := ioutil.ReadFile(rs.path)
 	if err != nil {
 		return nil, err
 	}
 
-	return rankingsFrom(data), nil
+	// Older files are migrated the next time the rankings are saved
+	rankings, err := Decode(data, info.ModTime())
+	if err != nil {
+		return nil, err
+	}
+	rankings.HalfLife = rs.halfLife
+	return rankings, nil
 }
 
-func (rs RankingService) SaveRankings(rankings ranking.Rankings) error {
-	data, _ := json.Marshal(rankedRoomsFrom(rankings))
+func (rs RankingService) SaveRankings(rankings *ranking.Rankings) error {
+	data, _ := Encode(rankings)
 
 	err := ioutil.WriteFile(rs.path, data, 0644)
 	return err
 }
+
+// Encode returns the rankings in the format of the rankings file
+func Encode(rankings *ranking.Rankings) ([]byte, error) {
+	return json.Marshal(fileFrom(rankings))
+}
+
+// Decode reads rankings in the format of the rankings file or any earlier
+// format of it. Rooms which don't know when they were last used are assumed
+// to have been used when the rankings were modified.
+func Decode(data []byte, modified time.Time) (*ranking.Rankings, error) {
+	var file rankingsFile
+	var err error
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		// The global ranks of a file written before buckets were introduced
+		err = json.Unmarshal(data, &file.Global)
+	} else {
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if file.Version < version {
+		migrate(&file, modified)
+	}
+
+	return &ranking.Rankings{
+		Buckets:   bucketsFrom(file),
+		Favorites: file.Favorites,
+		Blocked:   file.Blocked,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/ranking"
+)
+
+func newTestService(t *testing.T) (ranking.RankingService, string) {
+	dir, err := ioutil.TempDir("", "bgc-rankings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	rs, err := NewRankingService(dir + string(filepath.Separator))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rs, filepath.Join(dir, "rankings.json")
+}
+
+func TestRankingService_SaveRankings(t *testing.T) {
+	rs, _ := newTestService(t)
+	room := booking.Room{Provider: "A", Id: "A", Seats: 4}
+	used := time.Date(2019, 10, 14, 12, 0, 0, 0, time.UTC)
+	lunch := ranking.BucketOf(used)
+
+	rankings, err := rs.GetRankings()
+	assert.NoError(t, err)
+	assert.Empty(t, rankings.Buckets[ranking.Global])
+	assert.Equal(t, rankings.HalfLife, ranking.DefaultHalfLife)
+
+	rankings.Buckets[ranking.Global] = ranking.Ranks{room: {Penalty: 3, LastUsed: used}}
+	rankings.Buckets[lunch] = ranking.Ranks{room: {Penalty: 1, LastUsed: used}}
+	assert.NoError(t, rs.SaveRankings(rankings))
+
+	saved, err := rs.GetRankings()
+	assert.NoError(t, err)
+	assert.Equal(t, rankings, saved)
+}
+
+func TestRankingService_Migrate(t *testing.T) {
+	rs, path := newTestService(t)
+	room := booking.Room{Provider: "A", Id: "A", Seats: 4}
+	modified := time.Date(2019, 10, 14, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"flat array", `[{"Room":{"Provider":"A","Id":"A","Seats":4,"Campus":""},"Rank":7}]`},
+		{"buckets", `{"Version":2,"Global":[{"Room":{"Provider":"A","Id":"A","Seats":4,"Campus":""},"Rank":7}],"Buckets":{}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, ioutil.WriteFile(path, []byte(tt.data), 0644))
+			assert.NoError(t, os.Chtimes(path, modified, modified))
+
+			rankings, err := rs.GetRankings()
+			assert.NoError(t, err)
+			assert.Equal(t, ranking.Ranks{room: {Penalty: 7, LastUsed: modified}}, rankings.Buckets[ranking.Global],
+				"Rooms should have been last used when the file was written")
+		})
+	}
+
+	rankings, err := rs.GetRankings()
+	assert.NoError(t, err)
+	assert.NoError(t, rs.SaveRankings(rankings))
+
+	// Saving the rankings migrates the file, so the rooms are still last used
+	// when the old file was written
+	assert.NoError(t, os.Chtimes(path, time.Now(), time.Now()))
+	saved, err := rs.GetRankings()
+	assert.NoError(t, err)
+	assert.True(t, saved.Buckets[ranking.Global][room].LastUsed.Equal(modified))
+}
@@ -0,0 +1,63 @@
+package ranking
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+// Pattern matches the names of rooms, ignoring case. A pattern enclosed in
+// slashes, such as "/^EG-[0-9]+$/", is a regular expression, other patterns
+// are either the name of a room or contain the wildcards * and ?.
+type Pattern string
+
+// Compile checks that the pattern is valid and returns the expression that
+// room names are matched against
+func (p Pattern) Compile() (*regexp.Regexp, error) {
+	s := string(p)
+	if len(s) > 1 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		return regexp.Compile("(?i)" + s[1:len(s)-1])
+	}
+
+	expr := regexp.QuoteMeta(s)
+	expr = strings.Replace(expr, `\*`, ".*", -1)
+	expr = strings.Replace(expr, `\?`, ".", -1)
+	return regexp.Compile("(?i)^" + expr + "$")
+}
+
+// compiled are the expressions of the patterns that have been matched, nil
+// for invalid patterns, so that every room doesn't compile them again
+var compiled = struct {
+	sync.Mutex
+	exprs map[Pattern]*regexp.Regexp
+}{exprs: make(map[Pattern]*regexp.Regexp)}
+
+// Match reports whether the name of the room matches the pattern, invalid
+// patterns match no rooms
+func (p Pattern) Match(room booking.Room) bool {
+	expr := p.expr()
+	return expr != nil && expr.MatchString(room.Id)
+}
+
+func (p Pattern) expr() *regexp.Regexp {
+	compiled.Lock()
+	defer compiled.Unlock()
+
+	expr, ok := compiled.exprs[p]
+	if !ok {
+		expr, _ = p.Compile()
+		compiled.exprs[p] = expr
+	}
+	return expr
+}
+
+func matchAny(patterns []Pattern, room booking.Room) bool {
+	for _, p := range patterns {
+		if p.Match(room) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package ranking
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+)
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern Pattern
+		room    string
+		match   bool
+	}{
+		{"EG-2515", "EG-2515", true},
+		{"eg-2515", "EG-2515", true},
+		{"EG-2515", "EG-25150", false},
+		{"EG-*", "EG-2515", true},
+		{"EG-*", "SB-G510", false},
+		{"SB-G5?0", "SB-G510", true},
+		{"SB-G5?0", "SB-G5100", false},
+		{"/^sb-g[0-9]+$/", "SB-G510", true},
+		{"/g5/", "SB-G510", true},
+		{"/^EG/", "SB-G510", false},
+		{"/[/", "[", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, tt.pattern.Match(booking.Room{Id: tt.room}), "%s matching %s", tt.pattern, tt.room)
+	}
+}
+
+func TestPatternCompile(t *testing.T) {
+	_, err := Pattern("/[/").Compile()
+	assert.Error(t, err)
+
+	_, err = Pattern("[").Compile()
+	assert.NoError(t, err, "Patterns without slashes are never invalid")
+}
@@ -1,50 +1,274 @@
 package ranking
 
 import (
+	"fmt"
+	"math"
 	"sort"
+	"strings"
+	"time"
 
 	"sidus.io/boogrocha/internal/booking"
 )
 
+// DefaultHalfLife is how long it takes for the penalties of rooms to halve if
+// nothing else is configured
+const DefaultHalfLife = 90 * 24 * time.Hour
+
 /**
-Ranking representation, a low rank is good
+Rank representation, a low penalty is good
 */
-type Rankings map[booking.Room]uint64
+type Rank struct {
+	Penalty uint64
+	// LastUsed is when the room was last offered or selected, the penalty
+	// decays from then
+	LastUsed time.Time
+}
 
-func (r Rankings) Sort(rooms []booking.Room) []booking.Room {
-	sort.Slice(rooms, func(i, j int) bool {
-		if r[rooms[i]]-r[rooms[j]] != 0 {
-			return r[rooms[i]] < r[rooms[j]]
-		} else {
-			return rooms[i].Id > rooms[j].Id
+type Ranks map[booking.Room]Rank
+
+// Bucket identifies a weekday and time of day that rooms are ranked for,
+// such as "monday-morning"
+type Bucket string
+
+// Global is the bucket that ranks rooms regardless of when they are booked
+const Global = Bucket("")
+
+// The times of day rooms are ranked for and the hour each of them starts
+var timesOfDay = []struct {
+	name  string
+	start int
+}{
+	{"morning", 0},
+	{"lunch", 11},
+	{"afternoon", 13},
+	{"evening", 17},
+}
+
+// BucketOf returns the bucket of bookings starting at t, in the time zone of t
+func BucketOf(t time.Time) Bucket {
+	name := timesOfDay[0].name
+	for _, tod := range timesOfDay {
+		if t.Hour() >= tod.start {
+			name = tod.name
 		}
+	}
+	return Bucket(fmt.Sprintf("%s-%s", strings.ToLower(t.Weekday().String()), name))
+}
+
+// Rankings of rooms by bucket. The Global bucket is updated with every
+// selection and is used when the bucket of a booking can't tell rooms apart.
+type Rankings struct {
+	Buckets map[Bucket]Ranks
+	// Favorites are always ranked above other rooms
+	Favorites []Pattern
+	// Blocked rooms are never offered
+	Blocked []Pattern
+	// HalfLife is how long it takes for a penalty to halve, so that rooms
+	// selected recently weigh more than rooms selected long ago. Penalties
+	// don't decay if it isn't set.
+	HalfLife time.Duration
+	// Now returns the current time, time.Now is used if it isn't set
+	Now func() time.Time
+}
+
+func (r *Rankings) now() time.Time {
+	if r.Now == nil {
+		return time.Now()
+	}
+	return r.Now()
+}
+
+// Penalty returns the penalty of the room in the bucket, decayed until now
+func (r *Rankings) Penalty(bucket Bucket, room booking.Room) uint64 {
+	return r.contribution(bucket, room).Penalty
+}
+
+func (r *Rankings) contribution(bucket Bucket, room booking.Room) Contribution {
+	rank := r.Buckets[bucket][room]
+	c := Contribution{
+		Bucket:   bucket,
+		Penalty:  rank.Penalty,
+		Stored:   rank.Penalty,
+		LastUsed: rank.LastUsed,
+	}
+	age := r.now().Sub(rank.LastUsed)
+	if r.HalfLife > 0 && age > 0 {
+		c.Penalty = uint64(math.Round(float64(rank.Penalty) * math.Exp2(-float64(age)/float64(r.HalfLife))))
+	}
+	return c
+}
+
+// IsFavorite reports whether the room is one of the favorites
+func (r *Rankings) IsFavorite(room booking.Room) bool {
+	return matchAny(r.Favorites, room)
+}
+
+// IsBlocked reports whether the room is blocked
+func (r *Rankings) IsBlocked(room booking.Room) bool {
+	return matchAny(r.Blocked, room)
+}
+
+// Filter returns the rooms which aren't blocked. The rankings may be nil,
+// then no rooms are blocked.
+func (r *Rankings) Filter(rooms []booking.Room) []booking.Room {
+	if r == nil || len(r.Blocked) == 0 {
+		return rooms
+	}
+	kept := make([]booking.Room, 0, len(rooms))
+	for _, room := range rooms {
+		if !r.IsBlocked(room) {
+			kept = append(kept, room)
+		}
+	}
+	return kept
+}
+
+// WithoutLists returns the rankings without the favorite and blocked rooms
+func (r *Rankings) WithoutLists() *Rankings {
+	without := *r
+	without.Favorites, without.Blocked = nil, nil
+	return &without
+}
+
+// Contribution is the penalty of a room in a bucket
+type Contribution struct {
+	Bucket Bucket
+	// Penalty is the stored penalty decayed until now
+	Penalty uint64
+	// Stored is the penalty when the room was last used
+	Stored   uint64
+	LastUsed time.Time
+}
+
+// Score is what Sort orders a room by, it's explained by its contributions
+type Score struct {
+	Room booking.Room
+	// Favorite rooms are ranked above all other rooms
+	Favorite bool
+	// Bucket is the penalty of the room in the bucket of the booking, which
+	// decides unless it's a tie
+	Bucket Contribution
+	// Global is the penalty of the room in the Global bucket, which decides
+	// ties in the bucket of the booking. Remaining ties are decided by the
+	// names of the rooms in reverse order.
+	Global Contribution
+}
+
+// Less reports whether the room of s is ranked above the room of o
+func (s Score) Less(o Score) bool {
+	if s.Favorite != o.Favorite {
+		return s.Favorite
+	}
+	if s.Bucket.Penalty != o.Bucket.Penalty {
+		return s.Bucket.Penalty < o.Bucket.Penalty
+	}
+	if s.Global.Penalty != o.Global.Penalty {
+		return s.Global.Penalty < o.Global.Penalty
+	}
+	return s.Room.Id > o.Room.Id
+}
+
+// Score returns the score of the room for bookings starting at start, only
+// the global penalties are used if start is zero
+func (r *Rankings) Score(room booking.Room, start time.Time) Score {
+	bucket := Global
+	if !start.IsZero() {
+		bucket = BucketOf(start)
+	}
+	return Score{
+		Room:     room,
+		Favorite: r.IsFavorite(room),
+		Bucket:   r.contribution(bucket, room),
+		Global:   r.contribution(Global, room),
+	}
+}
+
+// Sort orders the rooms with the favorites first and then by their penalties
+// in the bucket of start, falling back to the global penalties. Only the
+// global penalties are used if start is zero.
+func (r *Rankings) Sort(rooms []booking.Room, start time.Time) []booking.Room {
+	scores := make(map[booking.Room]Score, len(rooms))
+	for _, room := range rooms {
+		scores[room] = r.Score(room, start)
+	}
+	sort.Slice(rooms, func(i, j int) bool {
+		return scores[rooms[i]].Less(scores[rooms[j]])
 	})
 	return rooms
 }
 
-func (r Rankings) Update(selected booking.Room, pool []booking.Room) {
+// Rooms returns every room with a penalty in the bucket or globally
+func (r *Rankings) Rooms(bucket Bucket) []booking.Room {
+	var rooms []booking.Room
+	seen := make(map[booking.Room]bool)
+	for _, b := range []Bucket{bucket, Global} {
+		for room := range r.Buckets[b] {
+			if !seen[room] {
+				seen[room] = true
+				rooms = append(rooms, room)
+			}
+		}
+	}
+	return rooms
+}
+
+// Reset forgets the penalties of the room in every bucket
+func (r *Rankings) Reset(room booking.Room) {
+	for _, ranks := range r.Buckets {
+		delete(ranks, room)
+	}
+}
+
+// Update ranks the selected room above the rest of the pool, both in the
+// bucket of start and globally
+func (r *Rankings) Update(selected booking.Room, pool []booking.Room, start time.Time) {
+	if r.Buckets == nil {
+		r.Buckets = make(map[Bucket]Ranks)
+	}
+	now := r.now()
+	for _, bucket := range []Bucket{BucketOf(start), Global} {
+		ranks := r.Buckets[bucket]
+		if ranks == nil {
+			ranks = make(Ranks)
+			r.Buckets[bucket] = ranks
+		}
+
+		// The penalties decay until now before they are added to
+		ranks[selected] = Rank{Penalty: r.Penalty(bucket, selected), LastUsed: now}
+		for _, room := range pool {
+			ranks[room] = Rank{Penalty: r.Penalty(bucket, room), LastUsed: now}
+		}
+		ranks.Update(selected, pool)
+	}
+}
+
+func (r Ranks) Update(selected booking.Room, pool []booking.Room) {
 	for _, room := range pool {
 		if room != selected {
+			rank := r[room]
 			diff := uint64(0)
-			if r[room] > r[selected] {
+			if rank.Penalty > r[selected].Penalty {
 				diff = 1
 			} else {
 				diff = 5
 			}
-			if uint64(diff+r[room]) < r[room] { // Handle overflow
+			if uint64(diff+rank.Penalty) < rank.Penalty { // Handle overflow
 				r.Normalize(1000)
+				rank = r[room]
 			}
-			r[room] = diff + r[room]
+			rank.Penalty = diff + rank.Penalty
+			r[room] = rank
 		}
 	}
 }
 
-func (r Rankings) Normalize(amount uint64) {
-	for key := range r {
-		if r[key] < uint64(r[key]-amount) {
-			r[key] = 0
+func (r Ranks) Normalize(amount uint64) {
+	for key, rank := range r {
+		if rank.Penalty < uint64(rank.Penalty-amount) {
+			rank.Penalty = 0
 		} else {
-			r[key] = r[key] - amount
+			rank.Penalty = rank.Penalty - amount
 		}
+		r[key] = rank
 	}
 }
@@ -1,6 +1,6 @@
 package ranking
 
 type RankingService interface {
-	GetRankings() (Rankings, error)
-	SaveRankings(rankings Rankings) error
+	GetRankings() (*Rankings, error)
+	SaveRankings(rankings *Rankings) error
 }
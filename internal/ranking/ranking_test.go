@@ -2,6 +2,7 @@ package ranking
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -30,14 +31,14 @@ func TestSort(t *testing.T) {
 		Id:       "E",
 	}
 
-	ranking := Rankings{
-		r1: 2,
-		r4: 2,
-		r2: 1,
-		r3: 1,
-	}
+	ranking := Rankings{Buckets: map[Bucket]Ranks{Global: {
+		r1: {Penalty: 2},
+		r4: {Penalty: 2},
+		r2: {Penalty: 1},
+		r3: {Penalty: 1},
+	}}}
 
-	sorted := ranking.Sort([]booking.Room{r1, r4, r2, r3, r5})
+	sorted := ranking.Sort([]booking.Room{r1, r4, r2, r3, r5}, time.Time{})
 	assert.Equal(t, len(sorted), 5, "Length should be conserved when sorting")
 
 	// Make sure list was sorted with lowest ranking first and reverse alphabetic order
@@ -71,16 +72,167 @@ func TestUpdate(t *testing.T) {
 		Id:       "E",
 	}
 
-	ranking := Rankings{
-		r1: 5,
-		r2: 0,
-		r3: 1,
+	ranking := Ranks{
+		r1: {Penalty: 5},
+		r2: {Penalty: 0},
+		r3: {Penalty: 1},
 	}
 
 	ranking.Update(r3, []booking.Room{r1, r4, r5})
 
-	assert.Equal(t, ranking[r3], uint64(1), "Selected rooms ranking should not be effected")
-	assert.Equal(t, ranking[r2], uint64(0), "Elements outside of pool should not be effected")
-	assert.True(t, ranking[r4] > (ranking[r1]-5), "Lesser elements should not be effected as mush as greater elements")
-	assert.True(t, ranking[r5] > 0, "Not selected elements should be punished")
+	assert.Equal(t, ranking[r3].Penalty, uint64(1), "Selected rooms ranking should not be effected")
+	assert.Equal(t, ranking[r2].Penalty, uint64(0), "Elements outside of pool should not be effected")
+	assert.True(t, ranking[r4].Penalty > (ranking[r1].Penalty-5), "Lesser elements should not be effected as mush as greater elements")
+	assert.True(t, ranking[r5].Penalty > 0, "Not selected elements should be punished")
+}
+
+func TestBucketOf(t *testing.T) {
+	at := func(day, hour int) time.Time {
+		return time.Date(2019, 10, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	assert.Equal(t, BucketOf(at(14, 8)), Bucket("monday-morning"))
+	assert.Equal(t, BucketOf(at(14, 11)), Bucket("monday-lunch"))
+	assert.Equal(t, BucketOf(at(15, 13)), Bucket("tuesday-afternoon"))
+	assert.Equal(t, BucketOf(at(20, 18)), Bucket("sunday-evening"))
+}
+
+func TestRankingsSort(t *testing.T) {
+	quiet := booking.Room{Provider: "A", Id: "Quiet"}
+	canteen := booking.Room{Provider: "A", Id: "Canteen"}
+	other := booking.Room{Provider: "A", Id: "Other"}
+
+	morning := time.Date(2019, 10, 14, 8, 0, 0, 0, time.UTC)
+	lunch := time.Date(2019, 10, 14, 12, 0, 0, 0, time.UTC)
+	evening := time.Date(2019, 10, 14, 18, 0, 0, 0, time.UTC)
+
+	rankings := Rankings{Buckets: map[Bucket]Ranks{
+		Global:            {quiet: {Penalty: 10}, canteen: {Penalty: 10}, other: {Penalty: 0}},
+		BucketOf(morning): {canteen: {Penalty: 5}},
+		BucketOf(lunch):   {quiet: {Penalty: 5}, other: {Penalty: 5}},
+	}}
+
+	assert.Equal(t, []booking.Room{other, quiet, canteen},
+		rankings.Sort([]booking.Room{quiet, canteen, other}, morning), "Ties in the bucket should fall back to global ranks")
+	assert.Equal(t, []booking.Room{canteen, other, quiet},
+		rankings.Sort([]booking.Room{quiet, canteen, other}, lunch))
+	assert.Equal(t, []booking.Room{other, quiet, canteen},
+		rankings.Sort([]booking.Room{quiet, canteen, other}, evening), "Global ranks should be used without a bucket")
+	assert.Equal(t, []booking.Room{other, quiet, canteen},
+		rankings.Sort([]booking.Room{quiet, canteen, other}, time.Time{}))
+}
+
+func TestRankingsUpdate(t *testing.T) {
+	quiet := booking.Room{Provider: "A", Id: "Quiet"}
+	canteen := booking.Room{Provider: "A", Id: "Canteen"}
+
+	lunch := time.Date(2019, 10, 14, 12, 0, 0, 0, time.UTC)
+	rankings := Rankings{}
+
+	rankings.Update(canteen, []booking.Room{quiet, canteen}, lunch)
+
+	assert.True(t, rankings.Penalty(BucketOf(lunch), quiet) > rankings.Penalty(BucketOf(lunch), canteen), "The bucket should be updated")
+	assert.True(t, rankings.Penalty(Global, quiet) > rankings.Penalty(Global, canteen), "The global ranks should be updated")
+	assert.Equal(t, len(rankings.Buckets), 2, "Other buckets should not be effected")
+}
+
+func TestDecay(t *testing.T) {
+	loved := booking.Room{Provider: "A", Id: "Loved"}
+	recent := booking.Room{Provider: "A", Id: "Recent"}
+
+	now := time.Date(2019, 10, 14, 8, 0, 0, 0, time.UTC)
+	longAgo := now.AddDate(-2, 0, 0)
+	rankings := Rankings{
+		Buckets: map[Bucket]Ranks{Global: {
+			loved:  {Penalty: 0, LastUsed: longAgo},
+			recent: {Penalty: 800, LastUsed: now.Add(-30 * 24 * time.Hour)},
+		}},
+		HalfLife: 30 * 24 * time.Hour,
+		Now: func() time.Time {
+			return now
+		},
+	}
+
+	assert.Equal(t, rankings.Penalty(Global, recent), uint64(400), "Penalties should halve every half-life")
+
+	rankings.Update(recent, []booking.Room{loved, recent}, now)
+	assert.Equal(t, rankings.Buckets[Global][recent], Rank{Penalty: 400, LastUsed: now}, "Penalties should decay until they are updated")
+	assert.Equal(t, rankings.Buckets[Global][loved], Rank{Penalty: 5, LastUsed: now})
+
+	now = now.Add(30 * 24 * time.Hour * 10)
+	assert.Equal(t, []booking.Room{recent, loved}, rankings.Sort([]booking.Room{loved, recent}, time.Time{}),
+		"Old selections should be outweighed by new selections")
+
+	rankings.HalfLife = 0
+	assert.Equal(t, rankings.Penalty(Global, recent), uint64(400), "Penalties should not decay without a half-life")
+}
+
+func TestFavorites(t *testing.T) {
+	basement := booking.Room{Provider: "A", Id: "Basement"}
+	favorite := booking.Room{Provider: "A", Id: "Favorite"}
+	other := booking.Room{Provider: "A", Id: "Other"}
+
+	rankings := Rankings{
+		Buckets:   map[Bucket]Ranks{Global: {favorite: {Penalty: 10}}},
+		Favorites: []Pattern{"fav*"},
+		Blocked:   []Pattern{"basement"},
+	}
+
+	assert.Equal(t, []booking.Room{favorite, other, basement},
+		rankings.Sort([]booking.Room{basement, other, favorite}, time.Time{}), "Favorites should be first regardless of their penalties")
+	assert.True(t, rankings.IsBlocked(basement))
+	assert.False(t, rankings.IsBlocked(other))
+	assert.Equal(t, []booking.Room{other, favorite}, rankings.Filter([]booking.Room{basement, other, favorite}))
+	var none *Rankings
+	assert.Equal(t, []booking.Room{basement}, none.Filter([]booking.Room{basement}), "Without rankings no rooms are blocked")
+
+	without := rankings.WithoutLists()
+	assert.Equal(t, []booking.Room{other, basement, favorite},
+		without.Sort([]booking.Room{basement, other, favorite}, time.Time{}))
+	assert.False(t, without.IsBlocked(basement))
+	assert.Equal(t, []booking.Room{basement, other}, without.Filter([]booking.Room{basement, other}))
+	assert.Equal(t, []Pattern{"fav*"}, rankings.Favorites, "The lists of the rankings should be kept")
+}
+
+func TestScore(t *testing.T) {
+	room := booking.Room{Provider: "A", Id: "A"}
+	now := time.Date(2019, 10, 14, 8, 0, 0, 0, time.UTC)
+	used := now.Add(-30 * 24 * time.Hour)
+
+	rankings := Rankings{
+		Buckets: map[Bucket]Ranks{
+			Global:        {room: {Penalty: 20, LastUsed: used}},
+			BucketOf(now): {room: {Penalty: 4, LastUsed: now}},
+		},
+		Favorites: []Pattern{"a"},
+		HalfLife:  30 * 24 * time.Hour,
+		Now: func() time.Time {
+			return now
+		},
+	}
+
+	assert.Equal(t, Score{
+		Room:     room,
+		Favorite: true,
+		Bucket:   Contribution{Bucket: BucketOf(now), Penalty: 4, Stored: 4, LastUsed: now},
+		Global:   Contribution{Bucket: Global, Penalty: 10, Stored: 20, LastUsed: used},
+	}, rankings.Score(room, now))
+	assert.Equal(t, Global, rankings.Score(room, time.Time{}).Bucket.Bucket, "Only global penalties should be used without a time")
+}
+
+func TestReset(t *testing.T) {
+	a := booking.Room{Provider: "A", Id: "A"}
+	b := booking.Room{Provider: "A", Id: "B"}
+	lunch := BucketOf(time.Date(2019, 10, 14, 12, 0, 0, 0, time.UTC))
+
+	rankings := Rankings{Buckets: map[Bucket]Ranks{
+		Global: {a: {Penalty: 1}, b: {Penalty: 2}},
+		lunch:  {a: {Penalty: 3}},
+	}}
+
+	assert.ElementsMatch(t, []booking.Room{a, b}, rankings.Rooms(lunch))
+
+	rankings.Reset(a)
+	assert.Equal(t, []booking.Room{b}, rankings.Rooms(lunch))
+	assert.Equal(t, uint64(2), rankings.Penalty(Global, b), "Other rooms should not be effected")
 }
@@ -0,0 +1,146 @@
+package slot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/directory"
+	"sidus.io/boogrocha/internal/filter"
+	"sidus.io/boogrocha/internal/parallel"
+	"sidus.io/boogrocha/internal/ranking"
+)
+
+// Granularity is the smallest step bookings can be moved in at the providers
+const Granularity = 15 * time.Minute
+
+// Number of intervals that are probed for availability at the same time
+const maxConcurrentProbes = 4
+
+type Query struct {
+	From     time.Time
+	To       time.Time
+	Duration time.Duration
+	Filters  []filter.RoomFilter
+}
+
+type Suggestion struct {
+	Room  booking.Room
+	Start time.Time
+	End   time.Time
+}
+
+type probeResult struct {
+	start time.Time
+	rooms []booking.Room
+	err   error
+}
+
+// Search finds all intervals of the requested duration between From and To
+// where a room matching the filters and not blocked by the rankings is
+// available. The suggestions are ordered by the rankings of the rooms for
+// bookings starting at From and then by start time. If some providers are missing from the results a
+// *directory.PartialError is returned together with the suggestions that
+// were found.
+func Search(ctx context.Context, bs booking.BookingService, q Query, rankings *ranking.Rankings) ([]Suggestion, error) {
+	if q.Duration <= 0 || q.Duration%Granularity != 0 {
+		return nil, fmt.Errorf("duration has to be a multiple of %s", Granularity)
+	}
+
+	starts := candidates(q.From, q.To, q.Duration)
+	if len(starts) == 0 {
+		return nil, nil
+	}
+
+	results := probe(ctx, bs, starts, q.Duration)
+
+	var suggestions []Suggestion
+	var rooms []booking.Room
+	var partial *directory.PartialError
+	seen := make(map[booking.Room]bool)
+	for _, result := range results {
+		var p *directory.PartialError
+		if errors.As(result.err, &p) {
+			partial = partial.Merge(p)
+		} else if result.err != nil {
+			return nil, result.err
+		}
+		for _, room := range rankings.Filter(filter.Filter(result.rooms, q.Filters)) {
+			suggestions = append(suggestions, Suggestion{
+				Room:  room,
+				Start: result.start,
+				End:   result.start.Add(q.Duration),
+			})
+			if !seen[room] {
+				seen[room] = true
+				rooms = append(rooms, room)
+			}
+		}
+	}
+
+	if rankings != nil {
+		rooms = rankings.Sort(rooms, q.From)
+	}
+	order := make(map[booking.Room]int)
+	for i, room := range rooms {
+		order[room] = i
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if order[suggestions[i].Room] != order[suggestions[j].Room] {
+			return order[suggestions[i].Room] < order[suggestions[j].Room]
+		}
+		return suggestions[i].Start.Before(suggestions[j].Start)
+	})
+	if partial != nil {
+		return suggestions, partial
+	}
+	return suggestions, nil
+}
+
+// FirstPerRoom only keeps the first suggestion of every room
+func FirstPerRoom(suggestions []Suggestion) []Suggestion {
+	var first []Suggestion
+	seen := make(map[booking.Room]bool)
+	for _, s := range suggestions {
+		if !seen[s.Room] {
+			seen[s.Room] = true
+			first = append(first, s)
+		}
+	}
+	return first
+}
+
+// candidates returns every start time, aligned to the granularity, where
+// an interval of the given duration fits between from and to.
+func candidates(from, to time.Time, duration time.Duration) []time.Time {
+	start := from.Truncate(Granularity)
+	if start.Before(from) {
+		start = start.Add(Granularity)
+	}
+
+	var starts []time.Time
+	for ; !start.Add(duration).After(to); start = start.Add(Granularity) {
+		starts = append(starts, start)
+	}
+	return starts
+}
+
+func probe(ctx context.Context, bs booking.BookingService, starts []time.Time, duration time.Duration) []probeResult {
+	results := make([]probeResult, len(starts))
+	// The errors are kept with the results, as a missing provider shouldn't
+	// stop the other starts from being probed
+	_ = parallel.Do(ctx, len(starts), maxConcurrentProbes, func(ctx context.Context, i int) error {
+		rooms, err := bs.Available(ctx, starts[i], starts[i].Add(duration))
+		results[i] = probeResult{
+			start: starts[i],
+			rooms: rooms,
+			err:   err,
+		}
+		return nil
+	})
+	return results
+}
@@ -0,0 +1,186 @@
+package slot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"sidus.io/boogrocha/internal/booking"
+	"sidus.io/boogrocha/internal/booking/directory"
+	"sidus.io/boogrocha/internal/filter"
+	logfmt "sidus.io/boogrocha/internal/log/fmt"
+	"sidus.io/boogrocha/internal/ranking"
+)
+
+var (
+	small = booking.Room{Provider: "A", Id: "small", Seats: 2}
+	large = booking.Room{Provider: "A", Id: "large", Seats: 8}
+	other = booking.Room{Provider: "B", Id: "other", Seats: 6}
+)
+
+// scheduleService is a booking service where each room is busy during a
+// set of intervals.
+type scheduleService struct {
+	busy map[booking.Room][][2]time.Time
+}
+
+func (s *scheduleService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	return b, nil
+}
+
+func (s *scheduleService) UnBook(ctx context.Context, b booking.Booking) error {
+	return nil
+}
+
+func (s *scheduleService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	return nil, nil
+}
+
+func (s *scheduleService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	var available []booking.Room
+	for room, intervals := range s.busy {
+		free := true
+		for _, i := range intervals {
+			if start.Before(i[1]) && i[0].Before(end) {
+				free = false
+			}
+		}
+		if free {
+			available = append(available, room)
+		}
+	}
+	return available, nil
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2019, 10, 15, hour, minute, 0, 0, time.UTC)
+}
+
+func TestCandidates(t *testing.T) {
+	starts := candidates(at(8, 5), at(10, 0), time.Hour)
+	assert.Equal(t, []time.Time{at(8, 15), at(8, 30), at(8, 45), at(9, 0)}, starts)
+
+	assert.Empty(t, candidates(at(8, 0), at(8, 30), time.Hour))
+}
+
+func TestSearch(t *testing.T) {
+	bs := &scheduleService{busy: map[booking.Room][][2]time.Time{
+		small: nil,
+		large: {{at(8, 0), at(9, 0)}},
+		other: {{at(8, 0), at(10, 0)}},
+	}}
+
+	suggestions, err := Search(context.Background(), bs, Query{
+		From:     at(8, 0),
+		To:       at(10, 0),
+		Duration: time.Hour,
+		Filters: []filter.RoomFilter{func(r booking.Room) bool {
+			return r.Seats >= 4
+		}},
+	}, &ranking.Rankings{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Suggestion{{Room: large, Start: at(9, 0), End: at(10, 0)}}, suggestions)
+}
+
+func TestSearchRanking(t *testing.T) {
+	bs := &scheduleService{busy: map[booking.Room][][2]time.Time{
+		small: {{at(8, 0), at(8, 30)}},
+		large: nil,
+	}}
+
+	suggestions, err := Search(context.Background(), bs, Query{
+		From:     at(8, 0),
+		To:       at(9, 0),
+		Duration: 30 * time.Minute,
+	}, &ranking.Rankings{Buckets: map[ranking.Bucket]ranking.Ranks{
+		ranking.Global: {large: {Penalty: 10}, small: {Penalty: 0}},
+	}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Suggestion{
+		{Room: small, Start: at(8, 30), End: at(9, 0)},
+		{Room: large, Start: at(8, 0), End: at(8, 30)},
+		{Room: large, Start: at(8, 15), End: at(8, 45)},
+		{Room: large, Start: at(8, 30), End: at(9, 0)},
+	}, suggestions)
+
+	assert.Equal(t, []Suggestion{
+		{Room: small, Start: at(8, 30), End: at(9, 0)},
+		{Room: large, Start: at(8, 0), End: at(8, 30)},
+	}, FirstPerRoom(suggestions))
+}
+
+func TestSearchBlocked(t *testing.T) {
+	bs := &scheduleService{busy: map[booking.Room][][2]time.Time{
+		small: nil,
+		large: nil,
+	}}
+
+	suggestions, err := Search(context.Background(), bs, Query{
+		From:     at(8, 0),
+		To:       at(8, 30),
+		Duration: 30 * time.Minute,
+	}, &ranking.Rankings{Blocked: []ranking.Pattern{"small"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []Suggestion{{Room: large, Start: at(8, 0), End: at(8, 30)}}, suggestions)
+}
+
+func TestSearchPartial(t *testing.T) {
+	bs := directory.NewBookingService(map[string]booking.BookingService{
+		"A": &scheduleService{busy: map[booking.Room][][2]time.Time{large: nil}},
+		"B": &errorService{err: booking.ErrProviderUnreachable},
+	}, &logfmt.Logger{})
+
+	suggestions, err := Search(context.Background(), bs, Query{
+		From:     at(8, 0),
+		To:       at(9, 0),
+		Duration: time.Hour,
+	}, nil)
+
+	var partial *directory.PartialError
+	assert.True(t, errors.As(err, &partial), "got %v", err)
+	assert.Equal(t, []string{"B"}, partial.Missing())
+	assert.Equal(t, []Suggestion{{Room: large, Start: at(8, 0), End: at(9, 0)}}, suggestions)
+}
+
+func TestSearchInvalidDuration(t *testing.T) {
+	_, err := Search(context.Background(), &scheduleService{}, Query{
+		From:     at(8, 0),
+		To:       at(9, 0),
+		Duration: 20 * time.Minute,
+	}, nil)
+	assert.Error(t, err)
+}
+
+// errorService fails every call with err, or a generic error if err is nil
+type errorService struct {
+	err error
+}
+
+func (es *errorService) fail() error {
+	if es.err != nil {
+		return es.err
+	}
+	return fmt.Errorf("mock error")
+}
+
+func (es *errorService) Book(ctx context.Context, b booking.Booking) (booking.Booking, error) {
+	return booking.Booking{}, es.fail()
+}
+
+func (es *errorService) UnBook(ctx context.Context, b booking.Booking) error {
+	return es.fail()
+}
+
+func (es *errorService) MyBookings(ctx context.Context) ([]booking.Booking, error) {
+	return nil, es.fail()
+}
+
+func (es *errorService) Available(ctx context.Context, start time.Time, end time.Time) ([]booking.Room, error) {
+	return nil, es.fail()
+}